@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/GuntuAshok/oi/internal/stream"
+	"github.com/GuntuAshok/oi/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// needsConfirmation reports whether any of the selected built-in tools or MCP
+// servers may need the user to confirm its calls.
+func needsConfirmation(cfg *Config) bool {
+	sel, err := toolSelectionFor(cfg)
+	if err != nil {
+		return false
+	}
+	names := sel.builtinToolNames()
+	if slices.Contains(names, tools.WriteFile) ||
+		slices.Contains(names, tools.RunCommand) {
+		return true
+	}
+	for name, server := range enabledMCPs() {
+		if sel.includesServer(name) && server.sampling() == samplingAsk {
+			return true
+		}
+	}
+	return false
+}
+
+func newBuiltinTools(cfg *Config, sel toolSelection, confirm func(tool, summary string) bool) (*tools.Tools, error) {
+	bt, err := tools.New(tools.Config{
+		Root:         cfg.BuiltinTools.Root,
+		ShellTimeout: cfg.BuiltinTools.ShellTimeout,
+		MaxOutput:    cfg.BuiltinTools.MaxOutput,
+		Confirm:      confirm,
+	}, sel.builtinToolNames())
+	if err != nil {
+		return nil, modsError{err, "Could not setup built-in tools"}
+	}
+	return bt, nil
+}
+
+// withBuiltinTools adds the definitions of the enabled built-in tools to the
+// given MCP tools.
+func withBuiltinTools(mcps map[string][]mcp.Tool, bt *tools.Tools) map[string][]mcp.Tool {
+	defs := bt.Definitions()
+	if len(defs) == 0 {
+		return mcps
+	}
+	if mcps == nil {
+		mcps = map[string][]mcp.Tool{}
+	}
+	mcps[tools.ServerName] = defs
+	return mcps
+}
+
+// builtinToolCall calls a built-in tool if name refers to one.
+func builtinToolCall(ctx context.Context, bt *tools.Tools, name string, data []byte) (proto.ToolResult, bool, error) {
+	tool, ok := strings.CutPrefix(name, tools.ServerName+"_")
+	if !ok {
+		return proto.ToolResult{}, false, nil
+	}
+	content, err := bt.Call(ctx, tool, data)
+	return proto.ToolResult{Content: content}, true, err
+}
+
+// toolLimits returns the limits of the tool calling loop.
+func toolLimits(cfg *Config) (proto.ToolLimits, error) {
+	switch cfg.ToolResultTruncation {
+	case "", stream.TruncateHead, stream.TruncateMiddle, stream.TruncateTail:
+	default:
+		return proto.ToolLimits{}, modsError{
+			err: newUserErrorf(
+				"Unknown truncation %q, valid values are: %s, %s, %s",
+				cfg.ToolResultTruncation,
+				stream.TruncateHead,
+				stream.TruncateMiddle,
+				stream.TruncateTail,
+			),
+			reason: "Invalid tool-result-truncation setting.",
+		}
+	}
+	return proto.ToolLimits{
+		MaxRounds:     cfg.MaxToolRounds,
+		MaxParallel:   cfg.MaxParallelTools,
+		MaxResultSize: cfg.MaxToolResultSize,
+		Truncation:    cfg.ToolResultTruncation,
+	}, nil
+}
@@ -1,19 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"bytes"
 	"path/filepath"
 	"text/template"
 	"time"
 
 	_ "embed"
 
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/GuntuAshok/oi/internal/stream"
 	"github.com/adrg/xdg"
 	"github.com/caarlos0/duration"
 	"github.com/caarlos0/env/v9"
@@ -34,51 +36,82 @@ const (
 )
 
 var help = map[string]string{
-	"api":               "OpenAI compatible REST API (openai, localai, anthropic, ...)",
-	"apis":              "Aliases and endpoints for OpenAI compatible REST API",
-	"http-proxy":        "HTTP proxy to use for API requests",
-	"model":             "Default model (gpt-3.5-turbo, gpt-4, ggml-gpt4all-j...)",
-	"ask-model":         "Ask which model to use via interactive prompt",
-	"max-input-chars":   "Default character limit on input to model",
-	"format":            "Ask for the response to be formatted as markdown unless otherwise set",
-	"format-text":       "Text to append when using the -f flag",
-	"role":              "System role to use",
-	"roles":             "List of predefined system messages that can be used as roles",
-	"list-roles":        "List the roles defined in your configuration file",
-	"prompt":            "Include the prompt from the arguments and stdin, truncate stdin to specified number of lines",
-	"prompt-args":       "Include the prompt from the arguments in the response",
-	"raw":               "Render output as raw text when connected to a TTY",
-	"quiet":             "Quiet mode (hide the spinner while loading and stderr messages for success)",
-	"help":              "Show help and exit",
-	"version":           "Show version and exit",
-	"max-retries":       "Maximum number of times to retry API calls",
-	"no-limit":          "Turn off the client-side limit on the size of the input into the model",
-	"word-wrap":         "Wrap formatted output at specific width (default is 80)",
-	"max-tokens":        "Maximum number of tokens in response",
-	"temp":              "Temperature (randomness) of results, from 0.0 to 2.0, -1.0 to disable",
-	"stop":              "Up to 4 sequences where the API will stop generating further tokens",
-	"topp":              "TopP, an alternative to temperature that narrows response, from 0.0 to 1.0, -1.0 to disable",
-	"topk":              "TopK, only sample from the top K options for each subsequent token, -1 to disable",
-	"fanciness":         "Your desired level of fanciness",
-	"status-text":       "Text to show while generating",
-	"dirs":              "Print the directories in which oi store its data",
-	"reset-settings":    "Backup your old settings file and reset everything to the defaults",
-	"continue":          "Continue from the last response or a given save title",
-	"continue-last":     "Continue from the last response",
-	"no-cache":          "Disables caching of the prompt/response",
-	"title":             "Saves the current conversation with the given title",
-	"list":              "Lists saved conversations",
-	"delete":            "Deletes one or more saved conversations with the given titles or IDs",
-	"delete-older-than": "Deletes all saved conversations older than the specified duration; valid values are " + strings.EnglishJoin(duration.ValidUnits(), true),
-	"show":              "Show a saved conversation with the given title or ID",
-	"theme":             "Theme to use in the forms; valid choices are charm, catppuccin, dracula, and base16",
-	"show-last":         "Show the last saved conversation",
-	"mcp-servers":       "MCP Servers configurations",
-	"mcp-disable":       "Disable specific MCP servers",
-	"mcp-list":          "List all available MCP servers",
-	"mcp-list-tools":    "List all available tools from enabled MCP servers",
-	"mcp-timeout":       "Timeout for MCP server calls, defaults to 15 seconds",
-	"chat":              "Enter interactive chat mode (REPL)", // Add this line
+	"api":                    "OpenAI compatible REST API (openai, localai, anthropic, ...)",
+	"apis":                   "Aliases and endpoints for OpenAI compatible REST API",
+	"http-proxy":             "HTTP proxy to use for API requests",
+	"model":                  "Default model (gpt-3.5-turbo, gpt-4, ggml-gpt4all-j...)",
+	"ask-model":              "Ask which model to use via interactive prompt",
+	"max-input-chars":        "Default character limit on input to model",
+	"format":                 "Ask for the response to be formatted as markdown unless otherwise set",
+	"format-text":            "Text to append when using the -f flag",
+	"role":                   "System role to use",
+	"roles":                  "List of predefined system messages that can be used as roles",
+	"list-roles":             "List the roles defined in your configuration file",
+	"prompt":                 "Include the prompt from the arguments and stdin, truncate stdin to specified number of lines",
+	"prompt-args":            "Include the prompt from the arguments in the response",
+	"raw":                    "Render output as raw text when connected to a TTY",
+	"quiet":                  "Quiet mode (hide the spinner while loading and stderr messages for success)",
+	"help":                   "Show help and exit",
+	"version":                "Show version and exit",
+	"max-retries":            "Maximum number of times to retry API calls",
+	"no-limit":               "Turn off the client-side limit on the size of the input into the model",
+	"word-wrap":              "Wrap formatted output at specific width (default is 80)",
+	"max-tokens":             "Maximum number of tokens in response",
+	"temp":                   "Temperature (randomness) of results, from 0.0 to 2.0, -1.0 to disable",
+	"stop":                   "Up to 4 sequences where the API will stop generating further tokens",
+	"topp":                   "TopP, an alternative to temperature that narrows response, from 0.0 to 1.0, -1.0 to disable",
+	"topk":                   "TopK, only sample from the top K options for each subsequent token, -1 to disable",
+	"fanciness":              "Your desired level of fanciness",
+	"status-text":            "Text to show while generating",
+	"dirs":                   "Print the directories in which oi store its data",
+	"reset-settings":         "Backup your old settings file and reset everything to the defaults",
+	"continue":               "Continue from the last response or a given save title",
+	"continue-last":          "Continue from the last response",
+	"no-cache":               "Disables caching of the prompt/response",
+	"title":                  "Saves the current conversation with the given title",
+	"list":                   "Lists saved conversations, filtered by --tag, --model, --api, --since, --until and --pinned",
+	"search":                 "Search the messages of saved conversations, can be combined with --show, --continue or --delete like --list",
+	"fork":                   "Fork a saved conversation into a new one, optionally only up to a turn with <id>@<turn>, then continue it with the prompt if any",
+	"tree":                   "Show forks under the conversation they were forked from when listing",
+	"tag":                    "Tag the saved conversation, can be repeated; with --list, only list the conversations with all the tags",
+	"pinned":                 "Only list pinned conversations",
+	"since":                  "Only list conversations updated since a date, like 2006-01-02, or a duration ago, like 7d",
+	"until":                  "Only list conversations updated before a date, like 2006-01-02, or a duration ago, like 7d",
+	"sort":                   "Sort the listed conversations by: " + strings.EnglishJoin(listSorts, true),
+	"limit":                  "Maximum number of conversations to list",
+	"regenerate":             "Generate the last response of the conversation again, keeping the previous one as an alternative; defaults to the last conversation",
+	"undo":                   "Remove the last prompt and its responses from the conversation; defaults to the last conversation",
+	"edit-last":              "Edit the last prompt of the conversation in $EDITOR and generate its response again; defaults to the last conversation",
+	"delete":                 "Deletes one or more saved conversations with the given titles or IDs",
+	"delete-older-than":      "Deletes all saved conversations older than the specified duration; valid values are " + strings.EnglishJoin(duration.ValidUnits(), true),
+	"show":                   "Show a saved conversation with the given title or ID",
+	"theme":                  "Theme to use in the forms; valid choices are charm, catppuccin, dracula, and base16",
+	"show-last":              "Show the last saved conversation",
+	"mcp-servers":            "MCP Servers configurations",
+	"mcp-disable":            "Disable specific MCP servers",
+	"mcp-list":               "List all available MCP servers",
+	"mcp-list-tools":         "List all available tools from enabled MCP servers",
+	"mcp-timeout":            "Timeout for MCP server calls, defaults to 15 seconds; can be set per server with startup-timeout and call-timeout",
+	"mcp-config-files":       "MCP client configuration files (Claude Desktop, VS Code or Cursor style) to load servers from, relative to this file",
+	"mcp-output-dir":         "Directory where audio and binary MCP tool results are saved, defaults to a temporary directory",
+	"mcp-skip-failed":        "Skip MCP servers that fail to start instead of aborting the prompt",
+	"mcp-tools-cache-ttl":    "How long the tools listed by MCP servers are cached, 0 disables the cache",
+	"mcp-secrets-cache-ttl":  "How long to cache the output of the env-cmd and headers-cmd commands of MCP servers, 0 to run them every time",
+	"mcp-refresh":            "List the tools of MCP servers again instead of using the cache",
+	"mcp-sampling-model":     "Model answering the completion requests of MCP servers, defaults to the model of the prompt",
+	"title-model":            "Small model titling new conversations in the background after their first exchange, titles are the first line of the prompt otherwise",
+	"root":                   "Directory MCP servers may operate on, can be repeated; defaults to the roots configured for each server, or the git root or current directory",
+	"chat":                   "Enter interactive chat mode (REPL)", // Add this line
+	"tools":                  "Tools to expose: none, MCP server names, server_tool names or built-in tools (read_file, list_directory, grep, write_file, run_command or all); names may be glob patterns",
+	"role-tools":             "Tools exposed for each role when --tools is not given, in the same format as --tools; roles not listed get all MCP servers and no built-in tools",
+	"builtin-tools":          "Settings for the built-in tools: workspace root, shell command timeout and output limit",
+	"retention":              "Delete old conversations after saving: max age, max count, max total size in megabytes, whether pinned and --title conversations are kept; skip-pipelines doesn't save runs whose input or output isn't a terminal, unless --title is given",
+	"encryption":             "Encrypt the content of saved messages with a key derived from a passphrase, asked for or printed by key-cmd; the key is cached in plaintext in the cache directory for cache-ttl, 0 disables the cache; titles aren't encrypted, and encrypted messages aren't searchable",
+	"max-tool-rounds":        "Maximum number of rounds of tool calls per answer before the model must answer without tools, 0 means no limit",
+	"max-tool-result-size":   "Maximum size of a tool result sent to the model, in bytes, 0 means no limit",
+	"tool-result-truncation": "How tool results over max-tool-result-size are truncated: head, middle or tail",
+	"max-parallel-tools":     "Maximum number of tool calls run at the same time, 1 runs them one by one",
+	"tool-verbosity":         "How much of tool calls to show: normal, arguments (with timing) or full (with results)",
 }
 
 // Model represents the LLM model used in the API call.
@@ -152,6 +185,7 @@ type Config struct {
 	MaxTokens           int64      `yaml:"max-tokens" env:"MAX_TOKENS"`
 	MaxCompletionTokens int64      `yaml:"max-completion-tokens" env:"MAX_COMPLETION_TOKENS"`
 	MaxInputChars       int64      `yaml:"max-input-chars" env:"MAX_INPUT_CHARS"`
+	TitleModel          string     `yaml:"title-model" env:"TITLE_MODEL"`
 	Temperature         float64    `yaml:"temp" env:"TEMP"`
 	Stop                []string   `yaml:"stop" env:"STOP"`
 	TopP                float64    `yaml:"topp" env:"TOPP"`
@@ -184,31 +218,120 @@ type Config struct {
 	ShowLast            bool
 	Show                string
 	List                bool
+	Search              string
+	Fork                string
+	Tree                bool
+	Tags                []string
+	Pinned              bool
+	Since               time.Time
+	Until               time.Time
+	Sort                string
+	Limit               int
+	Regenerate          bool
+	Undo                bool
+	EditLast            bool
 	ListRoles           bool
 	Delete              []string
 	DeleteOlderThan     time.Duration
 	User                string
 	Chat                bool // Add this line
 
-	MCPServers   map[string]MCPServerConfig `yaml:"mcp-servers"`
-	MCPList      bool
-	MCPListTools bool
-	MCPDisable   []string
-	MCPTimeout   time.Duration `yaml:"mcp-timeout" env:"MCP_TIMEOUT"`
+	MCPServers         map[string]MCPServerConfig `yaml:"mcp-servers"`
+	MCPList            bool
+	MCPListTools       bool
+	MCPDisable         []string
+	MCPTimeout         time.Duration `yaml:"mcp-timeout" env:"MCP_TIMEOUT"`
+	MCPOutputDir       string        `yaml:"mcp-output-dir" env:"MCP_OUTPUT_DIR"`
+	MCPConfigFiles     []string      `yaml:"mcp-config-files"`
+	MCPSkipFailed      bool          `yaml:"mcp-skip-failed" env:"MCP_SKIP_FAILED"`
+	MCPSecretsCacheTTL time.Duration `yaml:"mcp-secrets-cache-ttl" env:"MCP_SECRETS_CACHE_TTL"`
+	MCPToolsCacheTTL   time.Duration `yaml:"mcp-tools-cache-ttl" env:"MCP_TOOLS_CACHE_TTL"`
+	MCPRefresh         bool
+	MCPSamplingModel   string `yaml:"mcp-sampling-model" env:"MCP_SAMPLING_MODEL"`
+	Roots              []string
+
+	Tools        []string
+	RoleTools    map[string][]string `yaml:"role-tools"`
+	BuiltinTools BuiltinToolsConfig  `yaml:"builtin-tools"`
+	Retention    RetentionConfig     `yaml:"retention"`
+	Encryption   EncryptionConfig    `yaml:"encryption"`
+
+	MaxToolRounds        int    `yaml:"max-tool-rounds" env:"MAX_TOOL_ROUNDS"`
+	MaxToolResultSize    int    `yaml:"max-tool-result-size" env:"MAX_TOOL_RESULT_SIZE"`
+	ToolResultTruncation string `yaml:"tool-result-truncation" env:"TOOL_RESULT_TRUNCATION"`
+	MaxParallelTools     int    `yaml:"max-parallel-tools" env:"MAX_PARALLEL_TOOLS"`
+	ToolVerbosity        string `yaml:"tool-verbosity" env:"TOOL_VERBOSITY"`
 
 	cacheReadFromID, cacheWriteToID, cacheWriteToTitle string
+	toolVerbosity                                      proto.Verbosity
+
+	// cacheDropLastTurn drops the last turn of the conversation read from
+	// the cache, which is kept as an alternative when saving, and
+	// cacheKeepModel uses the given model instead of the conversation's.
+	cacheDropLastTurn, cacheKeepModel bool
+
+	// listModel and listAPI filter the listed conversations, and are only
+	// set if --model or --api were given.
+	listModel, listAPI string
 }
 
 // MCPServerConfig holds configuration for an MCP server.
 type MCPServerConfig struct {
-	Type    string   `yaml:"type"`
-	Command string   `yaml:"command"`
-	Env     []string `yaml:"env"`
-	Args    []string `yaml:"args"`
-	URL     string   `yaml:"url"`
+	Type    string            `yaml:"type,omitempty"`
+	Command string            `yaml:"command,omitempty"`
+	Env     []string          `yaml:"env,omitempty"`
+	Args    []string          `yaml:"args,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// EnvCmd and HeadersCmd set environment variables and headers to the
+	// output of shell commands, e.g. pass show github.
+	EnvCmd     map[string]string `yaml:"env-cmd,omitempty"`
+	HeadersCmd map[string]string `yaml:"headers-cmd,omitempty"`
+
+	// StartupTimeout bounds the server initialization, defaults to mcp-timeout.
+	StartupTimeout time.Duration `yaml:"startup-timeout,omitempty"`
+	// CallTimeout bounds each tool call, defaults to mcp-timeout.
+	CallTimeout time.Duration `yaml:"call-timeout,omitempty"`
+	// Sampling is whether the server may ask the local model for
+	// completions: ask (the default), allow or deny.
+	Sampling string `yaml:"sampling,omitempty"`
+	// Roots are the directories the server may operate on, defaults to the
+	// git root or the current directory.
+	Roots []string `yaml:"roots,omitempty"`
+}
+
+// BuiltinToolsConfig holds configuration for the built-in tools.
+type BuiltinToolsConfig struct {
+	Root         string        `yaml:"root"`
+	ShellTimeout time.Duration `yaml:"shell-timeout"`
+	MaxOutput    int           `yaml:"max-output"`
 }
 
+// RetentionConfig holds the retention policy of saved conversations, zero
+// limits are unlimited. Pinned and titled conversations are kept unless
+// set otherwise.
+type RetentionConfig struct {
+	MaxAge        time.Duration `yaml:"max-age" env:"RETENTION_MAX_AGE"`
+	MaxCount      int           `yaml:"max-count" env:"RETENTION_MAX_COUNT"`
+	MaxSize       int64         `yaml:"max-size" env:"RETENTION_MAX_SIZE"`
+	KeepPinned    bool          `yaml:"keep-pinned" env:"RETENTION_KEEP_PINNED"`
+	KeepTitled    bool          `yaml:"keep-titled" env:"RETENTION_KEEP_TITLED"`
+	SkipPipelines bool          `yaml:"skip-pipelines" env:"RETENTION_SKIP_PIPELINES"`
+}
+
+// enabled returns whether any limit is set.
+func (r RetentionConfig) enabled() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0 || r.MaxSize > 0
+}
 
+// EncryptionConfig holds the encryption at rest of saved messages. The key
+// is derived from a passphrase, asked for or printed by KeyCmd, and cached
+// for CacheTTL.
+type EncryptionConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"ENCRYPTION_ENABLED"`
+	KeyCmd   string        `yaml:"key-cmd" env:"ENCRYPTION_KEY_CMD"`
+	CacheTTL time.Duration `yaml:"cache-ttl" env:"ENCRYPTION_CACHE_TTL"`
+}
 
 // UpdateConfigWithOllamaModels replaces apis -> ollama -> models with the current
 // models reported by the local Ollama instance, and updates default-model only if needed:
@@ -269,33 +392,8 @@ func UpdateConfigWithOllamaModels(configPath string, selectedModel ...string) (b
 	// --- Start of YAML manipulation logic ---
 	var chosenDefaultModel string // **Track the chosen model**
 
-	// helpers to work with mapping nodes
-	getMapValue := func(m *yaml.Node, key string) *yaml.Node {
-		if m == nil || m.Kind != yaml.MappingNode {
-			return nil
-		}
-		for i := 0; i < len(m.Content); i += 2 {
-			if k := m.Content[i]; k.Value == key {
-				return m.Content[i+1]
-			}
-		}
-		return nil
-	}
-	replaceMapValue := func(m *yaml.Node, key string, newVal *yaml.Node) bool {
-		if m == nil || m.Kind != yaml.MappingNode {
-			return false
-		}
-		for i := 0; i < len(m.Content); i += 2 {
-			if k := m.Content[i]; k.Value == key {
-				m.Content[i+1] = newVal
-				return true
-			}
-		}
-		return false
-	}
-
 	// Ensure 'apis' mapping exists
-	apisNode := getMapValue(doc, "apis")
+	apisNode := yamlMapValue(doc, "apis")
 	if apisNode == nil {
 		apisKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "apis"}
 		apisVal := &yaml.Node{Kind: yaml.MappingNode}
@@ -304,7 +402,7 @@ func UpdateConfigWithOllamaModels(configPath string, selectedModel ...string) (b
 	}
 
 	// Ensure 'ollama' mapping exists inside 'apis'
-	ollamaNode := getMapValue(apisNode, "ollama")
+	ollamaNode := yamlMapValue(apisNode, "ollama")
 	if ollamaNode == nil {
 		ollamaKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "ollama"}
 		ollamaVal := &yaml.Node{Kind: yaml.MappingNode}
@@ -334,7 +432,7 @@ func UpdateConfigWithOllamaModels(configPath string, selectedModel ...string) (b
 	// If modelsResp is nil (Ollama down), newModelsNode will be empty, effectively clearing the list
 
 	// Replace or add the 'models' node
-	if !replaceMapValue(ollamaNode, "models", newModelsNode) {
+	if !yamlReplaceMapValue(ollamaNode, "models", newModelsNode) {
 		ollamaNode.Content = append(ollamaNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "models"}, newModelsNode)
 	}
 
@@ -347,7 +445,7 @@ func UpdateConfigWithOllamaModels(configPath string, selectedModel ...string) (b
 			userSelection = selectedModel[0]
 		}
 
-		existingDefaultNode := getMapValue(doc, "default-model")
+		existingDefaultNode := yamlMapValue(doc, "default-model")
 
 		if userSelection != "" {
 			// If a model was explicitly selected, it always becomes the default.
@@ -423,9 +521,33 @@ func UpdateConfigWithOllamaModels(configPath string, selectedModel ...string) (b
 	return true, nil
 }
 
+// yamlMapValue returns the value of the given key in a mapping node.
+func yamlMapValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(m.Content); i += 2 {
+		if k := m.Content[i]; k.Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
 
-
-
+// yamlReplaceMapValue replaces the value of the given key in a mapping node,
+// returning false if the key does not exist.
+func yamlReplaceMapValue(m *yaml.Node, key string, newVal *yaml.Node) bool {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i < len(m.Content); i += 2 {
+		if k := m.Content[i]; k.Value == key {
+			m.Content[i+1] = newVal
+			return true
+		}
+	}
+	return false
+}
 
 func ensureConfig() (Config, error) {
 	var c Config
@@ -447,6 +569,17 @@ func ensureConfig() (Config, error) {
 	if err != nil {
 		return c, modsError{err, "Could not read settings file."}
 	}
+	// Decoding only sets the settings in the file, so these keep their
+	// defaults when missing from older configuration files.
+	defaults := defaultConfig()
+	c.MCPToolsCacheTTL = defaults.MCPToolsCacheTTL
+	c.MCPSecretsCacheTTL = defaults.MCPSecretsCacheTTL
+	c.MaxToolRounds = defaults.MaxToolRounds
+	c.MaxToolResultSize = defaults.MaxToolResultSize
+	c.ToolResultTruncation = defaults.ToolResultTruncation
+	c.MaxParallelTools = defaults.MaxParallelTools
+	c.Retention = defaults.Retention
+	c.Encryption = defaults.Encryption
 	if err := yaml.Unmarshal(content, &c); err != nil {
 		return c, modsError{err, "Could not parse settings file."}
 	}
@@ -470,6 +603,8 @@ func ensureConfig() (Config, error) {
 		c.WordWrap = 80
 	}
 
+	loadMCPConfigFiles(&c)
+
 	return c, nil
 }
 
@@ -511,7 +646,25 @@ func defaultConfig() Config {
 			"markdown": defaultMarkdownFormatText,
 			"json":     defaultJSONFormatText,
 		},
-		MCPTimeout: 15 * time.Second,
+		MCPTimeout:           15 * time.Second,
+		MCPToolsCacheTTL:     time.Hour,
+		MCPSecretsCacheTTL:   10 * time.Minute,
+		MaxToolRounds:        10,
+		MaxToolResultSize:    32 * 1024,
+		ToolResultTruncation: stream.TruncateMiddle,
+		MaxParallelTools:     4,
+		ToolVerbosity:        proto.VerbosityNormal.String(),
+		BuiltinTools: BuiltinToolsConfig{
+			ShellTimeout: 30 * time.Second,
+			MaxOutput:    16 * 1024,
+		},
+		Retention: RetentionConfig{
+			KeepPinned: true,
+			KeepTitled: true,
+		},
+		Encryption: EncryptionConfig{
+			CacheTTL: 15 * time.Minute,
+		},
 	}
 }
 
@@ -555,6 +708,19 @@ func usageFunc(cmd *cobra.Command) error {
 			)
 		}
 	})
+	if cmd.HasAvailableSubCommands() {
+		fmt.Println("\nCommands:")
+		for _, sub := range cmd.Commands() {
+			if !sub.IsAvailableCommand() {
+				continue
+			}
+			fmt.Printf(
+				"  %-44s %s\n",
+				stdoutStyles().Flag.Render(sub.Name()),
+				stdoutStyles().FlagDesc.Render(sub.Short),
+			)
+		}
+	}
 	if cmd.HasExample() {
 		fmt.Printf(
 			"\nExample:\n  %s\n  %s\n",
@@ -564,4 +730,4 @@ func usageFunc(cmd *cobra.Command) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
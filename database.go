@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the conversations database",
+	Long: `Manage the conversations database.
+
+The database is migrated to the latest schema whenever oi runs, after backing
+it up next to it. These commands show and apply the migrations explicitly.`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version of the database and its migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		applied, err := db.AppliedMigrations()
+		if err != nil {
+			return modsError{err, "Could not read the schema version."}
+		}
+		pending, err := db.PendingMigrations()
+		newer := errors.Is(err, errNewerSchema)
+		if err != nil && !newer {
+			return modsError{err, "Could not read the schema version."}
+		}
+
+		styles := stdoutStyles()
+		fmt.Printf("Database: %s %s\n", db.path, styles.Comment.Render(fileSize(db.path)))
+		version := 0
+		if len(applied) > 0 {
+			version = applied[len(applied)-1].Version
+		}
+		fmt.Printf("Schema version: %d of %d\n", version, latestSchemaVersion())
+		for _, m := range applied {
+			fmt.Printf("%3d  %s  %s\n", m.Version, styles.Comment.Render(m.AppliedAt.Local().Format("2006-01-02 15:04")), m.Description)
+		}
+		for _, m := range pending {
+			fmt.Printf("%3d  %s  %s\n", m.version, styles.Comment.Render(fmt.Sprintf("%-16s", "pending")), m.description)
+		}
+		if newer {
+			fmt.Fprintln(os.Stderr, "\nThe database was migrated by a newer version of oi, upgrade oi to use it.")
+		}
+		if len(pending) > 0 {
+			fmt.Fprintf(os.Stderr, "\nRun %s to apply the pending migrations.\n", stderrStyles().InlineCode.Render("oi db migrate"))
+		}
+		return nil
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply the pending migrations, after backing up the database",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		migrated, backup, err := db.Migrate()
+		if backup != "" && !config.Quiet {
+			fmt.Fprintln(os.Stderr, "Database backed up to", stderrStyles().InlineCode.Render(backup))
+		}
+		for _, m := range migrated {
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Applied migration %d: %s\n", m.version, m.description)
+			}
+		}
+		if err != nil {
+			return modsError{err, "Could not migrate the database."}
+		}
+		if !config.Quiet && len(migrated) == 0 {
+			fmt.Fprintf(os.Stderr, "The database is up to date, at version %d.\n", latestSchemaVersion())
+		}
+		return nil
+	},
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Rebuild the database to reclaim the space of deleted conversations",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		before := fileSize(db.path)
+		if err := db.Vacuum(); err != nil {
+			return modsError{err, "Could not vacuum the database."}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Vacuumed the database: %s → %s\n", before, fileSize(db.path))
+		}
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbStatusCmd, dbMigrateCmd, dbVacuumCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// fileSize returns the size of the file in megabytes.
+func fileSize(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "?"
+	}
+	return fmt.Sprintf("%.1f MB", float64(info.Size())/megabyte)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"crypto/cipher"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/GuntuAshok/oi/internal/proto"
 	"github.com/jmoiron/sqlx"
 	"modernc.org/sqlite"
 )
@@ -12,6 +14,7 @@ import (
 var (
 	errNoMatches   = errors.New("no conversations found")
 	errManyMatches = errors.New("multiple conversations matched the input")
+	errNoMessages  = errors.New("the conversation has no stored messages")
 )
 
 func handleSqliteErr(err error) error {
@@ -26,8 +29,19 @@ func handleSqliteErr(err error) error {
 	return err
 }
 
+// dbBusyTimeout is how long to wait for another oi process to finish writing
+// to the database, e.g. while migrating it.
+const dbBusyTimeout = 10 * time.Second
+
+// openDB opens the database at the given path. Transactions take the write
+// lock as they begin, so concurrent processes wait for each other instead of
+// failing when upgrading a read lock.
 func openDB(ds string) (*convoDB, error) {
-	db, err := sqlx.Open("sqlite", ds)
+	db, err := sqlx.Open("sqlite", fmt.Sprintf(
+		"%s?_pragma=busy_timeout(%d)&_txlock=immediate",
+		ds,
+		dbBusyTimeout.Milliseconds(),
+	))
 	if err != nil {
 		return nil, fmt.Errorf(
 			"could not create db: %w",
@@ -40,50 +54,12 @@ func openDB(ds string) (*convoDB, error) {
 			handleSqliteErr(err),
 		)
 	}
-	if _, err := db.Exec(`
-		CREATE TABLE
-		  IF NOT EXISTS conversations (
-		    id string NOT NULL PRIMARY KEY,
-		    title string NOT NULL,
-		    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
-		    CHECK (id <> ''),
-		    CHECK (title <> '')
-		  )
-	`); err != nil {
-		return nil, fmt.Errorf("could not migrate db: %w", err)
-	}
-	if _, err := db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id)
-	`); err != nil {
-		return nil, fmt.Errorf("could not migrate db: %w", err)
-	}
-	if _, err := db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title)
-	`); err != nil {
-		return nil, fmt.Errorf("could not migrate db: %w", err)
-	}
-
-	if !hasColumn(db, "model") {
-		if _, err := db.Exec(`
-			ALTER TABLE conversations ADD COLUMN model string
-		`); err != nil {
-			return nil, fmt.Errorf("could not migrate db: %w", err)
-		}
-	}
-	if !hasColumn(db, "api") {
-		if _, err := db.Exec(`
-			ALTER TABLE conversations ADD COLUMN api string
-		`); err != nil {
-			return nil, fmt.Errorf("could not migrate db: %w", err)
-		}
-	}
-
-	return &convoDB{db: db}, nil
+	return &convoDB{db: db, path: ds}, nil
 }
 
-func hasColumn(db *sqlx.DB, col string) bool {
+func hasColumn(db sqlx.Queryer, col string) bool {
 	var count int
-	if err := db.Get(&count, `
+	if err := sqlx.Get(db, &count, `
 		SELECT count(*)
 		FROM pragma_table_info('conversations') c
 		WHERE c.name = $1
@@ -95,6 +71,14 @@ func hasColumn(db *sqlx.DB, col string) bool {
 
 type convoDB struct {
 	db *sqlx.DB
+	// path is the database file, backed up before migrating.
+	path string
+
+	// encrypt is whether the saved messages are encrypted, with the key
+	// returned by key. aead is set once the key is known.
+	encrypt bool
+	key     encryptionKey
+	aead    cipher.AEAD
 }
 
 // Conversation in the database.
@@ -104,56 +88,211 @@ type Conversation struct {
 	UpdatedAt time.Time `db:"updated_at"`
 	API       *string   `db:"api"`
 	Model     *string   `db:"model"`
+	// ParentID is the conversation this one was forked from, and ForkTurn
+	// the number of its turns the fork started with.
+	ParentID *string `db:"parent_id"`
+	ForkTurn *int    `db:"fork_turn"`
+	// TitleSource is where the title comes from: the prompt, the user or
+	// the title model.
+	TitleSource *string `db:"title_source"`
+	// Snippet is the best matching part of the conversation, only set on
+	// search results.
+	Snippet *string `db:"snippet"`
+	// Pinned, Note and Tags are only set when listing or searching.
+	Pinned bool    `db:"pinned"`
+	Note   *string `db:"note"`
+	Tags   *string `db:"tags"`
+
+	// treePrefix draws the conversation in the fork tree, only set when
+	// listing with --tree.
+	treePrefix string
 }
 
 func (c *convoDB) Close() error {
 	return c.db.Close() //nolint: wrapcheck
 }
 
-func (c *convoDB) Save(id, title, api, model string) error {
-	res, err := c.db.Exec(c.db.Rebind(`
+// Save saves the conversation and its messages in a single transaction. A
+// nil messages slice leaves the stored messages, and the time the
+// conversation was last updated, untouched.
+func (c *convoDB) Save(id, title, api, model string, messages []proto.Message) error {
+	if messages != nil {
+		if err := c.unlockForSave(); err != nil {
+			return fmt.Errorf("Save: %w", err)
+		}
+	}
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := c.save(tx, id, title, api, model, messages); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// savedTurn is what is saved of a conversation at the end of a turn.
+type savedTurn struct {
+	ID, Title, TitleSource, API, Model string
+	Messages                           []proto.Message
+	Tags                               []string
+	// Replaced keeps the messages from ReplacedFrom on as an alternative
+	// before they are overwritten, for --regenerate and --edit-last.
+	Replaced     bool
+	ReplacedFrom int
+}
+
+// SaveTurn saves the conversation at the end of a turn, with its title
+// source, tags and the alternative of the replaced turn, in a single
+// transaction. It returns how many alternatives the conversation has.
+func (c *convoDB) SaveTurn(turn savedTurn) (int, error) {
+	if err := c.unlockForSave(); err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var alternatives int
+	if turn.Replaced {
+		if alternatives, err = keepAlternative(tx, turn.ID, turn.ReplacedFrom); err != nil {
+			return 0, fmt.Errorf("SaveTurn: %w", err)
+		}
+	}
+	if err := c.save(tx, turn.ID, turn.Title, turn.API, turn.Model, turn.Messages); err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+	if err := setTitleSource(tx, turn.ID, turn.TitleSource); err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+	if err := addTags(tx, turn.ID, turn.Tags); err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("SaveTurn: %w", err)
+	}
+	return alternatives, nil
+}
+
+// save upserts the conversation and, unless nil, its messages.
+func (c *convoDB) save(tx *sqlx.Tx, id, title, api, model string, messages []proto.Message) error {
+	res, err := tx.Exec(tx.Rebind(`
 		UPDATE conversations
 		SET
 		  title = ?,
 		  api = ?,
 		  model = ?,
-		  updated_at = CURRENT_TIMESTAMP
+		  updated_at = CASE
+		    WHEN ? THEN CURRENT_TIMESTAMP
+		    ELSE updated_at
+		  END
 		WHERE
 		  id = ?
-	`), title, api, model, id)
+	`), title, api, model, messages != nil, id)
 	if err != nil {
-		return fmt.Errorf("Save: %w", err)
+		return err //nolint:wrapcheck
 	}
 
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("Save: %w", err)
+		return err //nolint:wrapcheck
 	}
 
-	if rows > 0 {
-		return nil
+	if rows == 0 {
+		if _, err := tx.Exec(tx.Rebind(`
+			INSERT INTO
+			  conversations (id, title, api, model)
+			VALUES
+			  (?, ?, ?, ?)
+		`), id, title, api, model); err != nil {
+			return err //nolint:wrapcheck
+		}
 	}
 
-	if _, err := c.db.Exec(c.db.Rebind(`
+	if messages != nil {
+		if err := c.saveMessages(tx, id, model, messages); err != nil {
+			return err //nolint:wrapcheck
+		}
+		if err := indexMessages(tx, id); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// Fork saves a new conversation with the given messages, forked from the
+// parent at the given turn. The fork uses the API and model of its parent.
+func (c *convoDB) Fork(parent Conversation, id, title string, turn int, messages []proto.Message) error {
+	if err := c.unlockForSave(); err != nil {
+		return fmt.Errorf("Fork: %w", err)
+	}
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("Fork: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Exec(tx.Rebind(`
 		INSERT INTO
-		  conversations (id, title, api, model)
+		  conversations (id, title, api, model, parent_id, fork_turn)
 		VALUES
-		  (?, ?, ?, ?)
-	`), id, title, api, model); err != nil {
-		return fmt.Errorf("Save: %w", err)
+		  (?, ?, ?, ?, ?, ?)
+	`), id, title, parent.API, parent.Model, parent.ID, turn); err != nil {
+		return fmt.Errorf("Fork: %w", err)
+	}
+	var model string
+	if parent.Model != nil {
+		model = *parent.Model
+	}
+	if err := c.saveMessages(tx, id, model, messages); err != nil {
+		return fmt.Errorf("Fork: %w", err)
+	}
+	if err := indexMessages(tx, id); err != nil {
+		return fmt.Errorf("Fork: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Fork: %w", err)
+	}
 	return nil
 }
 
+// Delete deletes the conversation and its messages.
 func (c *convoDB) Delete(id string) error {
-	if _, err := c.db.Exec(c.db.Rebind(`
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, table := range []string{"messages", "messages_fts", "message_alternatives", "conversation_tags", "conversation_meta"} {
+		if _, err := tx.Exec(tx.Rebind(`
+			DELETE FROM `+table+`
+			WHERE
+			  conversation_id = ?
+		`), id); err != nil {
+			return fmt.Errorf("Delete: %w", err)
+		}
+	}
+	if _, err := tx.Exec(tx.Rebind(`
 		DELETE FROM conversations
 		WHERE
 		  id = ?
 	`), id); err != nil {
 		return fmt.Errorf("Delete: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
 	return nil
 }
 
@@ -274,13 +413,49 @@ func (c *convoDB) List() ([]Conversation, error) {
 	var convos []Conversation
 	if err := c.db.Select(&convos, `
 		SELECT
-		  *
+		  c.*,`+conversationMeta+`
 		FROM
-		  conversations
+		  conversations c`+conversationMetaJoin+`
 		ORDER BY
-		  updated_at DESC
+		  c.updated_at DESC
 	`); err != nil {
 		return convos, fmt.Errorf("List: %w", err)
 	}
 	return convos, nil
 }
+
+// SetTitleSource sets where the title of the conversation comes from.
+func (c *convoDB) SetTitleSource(id, source string) error {
+	if err := setTitleSource(c.db, id, source); err != nil {
+		return fmt.Errorf("SetTitleSource: %w", err)
+	}
+	return nil
+}
+
+func setTitleSource(ext sqlx.Ext, id, source string) error {
+	if _, err := ext.Exec(ext.Rebind(`
+		UPDATE conversations
+		SET
+		  title_source = ?
+		WHERE
+		  id = ?
+	`), source, id); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// SetUpdatedAt sets the time the conversation was last updated, so imported
+// conversations keep theirs.
+func (c *convoDB) SetUpdatedAt(id string, t time.Time) error {
+	if _, err := c.db.Exec(c.db.Rebind(`
+		UPDATE conversations
+		SET
+		  updated_at = ?
+		WHERE
+		  id = ?
+	`), t.UTC().Format(time.DateTime), id); err != nil {
+		return fmt.Errorf("SetUpdatedAt: %w", err)
+	}
+	return nil
+}
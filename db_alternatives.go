@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// migrateAlternatives creates the message_alternatives table, which keeps
+// the turns replaced by --regenerate and --edit-last. Each alternative is
+// numbered per conversation, and its messages keep their original index.
+func migrateAlternatives(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS message_alternatives (
+		    conversation_id string NOT NULL,
+		    alternative integer NOT NULL,
+		    idx integer NOT NULL,
+		    role string NOT NULL,
+		    content string NOT NULL DEFAULT '',
+		    images string,
+		    tool_calls string,
+		    model string,
+		    metrics string,
+		    created_at datetime NOT NULL,
+		    replaced_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+		    PRIMARY KEY (conversation_id, alternative, idx)
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// keepAlternative copies the messages of the conversation from the given
+// index on into a new alternative, and returns how many alternatives the
+// conversation has.
+func keepAlternative(tx *sqlx.Tx, id string, from int) (int, error) {
+	var next int
+	if err := tx.Get(&next, tx.Rebind(`
+		SELECT
+		  coalesce(max(alternative), 0) + 1
+		FROM
+		  message_alternatives
+		WHERE
+		  conversation_id = ?
+	`), id); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	res, err := tx.Exec(tx.Rebind(`
+		INSERT INTO
+		  message_alternatives (conversation_id, alternative, idx, role, content, images, tool_calls, model, metrics, created_at)
+		SELECT
+		  conversation_id, ?, idx, role, content, images, tool_calls, model, metrics, created_at
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		  AND idx >= ?
+	`), next, id, from)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return 0, err //nolint:wrapcheck
+	} else if rows == 0 {
+		next--
+	}
+	return next, nil
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// encryptedPrefix marks the encrypted values, it is followed by the base64
+// of the nonce and the sealed value.
+const encryptedPrefix = "oi:enc:v1:"
+
+// keyCheck is sealed with the key to tell whether a passphrase is right.
+const keyCheck = "oi"
+
+var (
+	errNoKey    = errors.New("there is no encryption key, run oi encryption encrypt to set one up")
+	errWrongKey = errors.New("wrong encryption passphrase")
+)
+
+// migrateEncryption creates the encryption_key table, which holds the salt
+// the key is derived with and a value sealed with it.
+func migrateEncryption(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS encryption_key (
+		    id integer NOT NULL PRIMARY KEY CHECK (id = 1),
+		    salt blob NOT NULL,
+		    check_value string NOT NULL,
+		    created_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now'))
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// encryptionKey returns the key of the given salt. The key is new if there
+// wasn't one yet, otherwise check tells whether it is the right one.
+type encryptionKey func(salt []byte, isNew bool, check func(key []byte) bool) ([]byte, error)
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+	return cipher.NewGCM(block) //nolint:wrapcheck
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// seal encrypts the value, bound to the conversation it belongs to.
+func seal(aead cipher.AEAD, id, value string) (string, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("could not encrypt: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(id))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value encrypted by seal.
+func open(aead cipher.AEAD, id, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("could not decrypt: invalid value")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt: %w", err)
+	}
+	return string(plain), nil
+}
+
+// storedKey is the encryption key as stored in the database.
+type storedKey struct {
+	Salt  []byte `db:"salt"`
+	Check string `db:"check_value"`
+}
+
+// cipher returns the cipher of the encrypted messages, getting the key the
+// first time. If setup is true, a key is set up if there is none yet.
+func (c *convoDB) cipher(setup bool) (cipher.AEAD, error) {
+	if c.aead != nil {
+		return c.aead, nil
+	}
+	if c.key == nil {
+		return nil, errNoKey
+	}
+
+	var stored storedKey
+	err := c.db.Get(&stored, `SELECT salt, check_value FROM encryption_key`)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !setup {
+			return nil, errNoKey
+		}
+		return c.setupKey()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the encryption key: %w", err)
+	}
+
+	key, err := c.key(stored.Salt, false, func(key []byte) bool {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return false
+		}
+		plain, err := open(aead, "", stored.Check)
+		return err == nil && plain == keyCheck
+	})
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	c.aead = aead
+	return aead, nil
+}
+
+// setupKey creates the encryption key.
+func (c *convoDB) setupKey() (cipher.AEAD, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("could not create the encryption key: %w", err)
+	}
+	key, err := c.key(salt, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	check, err := seal(aead, "", keyCheck)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.db.Exec(c.db.Rebind(`
+		INSERT INTO
+		  encryption_key (id, salt, check_value)
+		VALUES
+		  (1, ?, ?)
+	`), salt, check); err != nil {
+		return nil, fmt.Errorf("could not save the encryption key: %w", err)
+	}
+	c.aead = aead
+	return aead, nil
+}
+
+// openValue decrypts the value if it is encrypted.
+func (c *convoDB) openValue(id, value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	aead, err := c.cipher(false)
+	if err != nil {
+		return "", err
+	}
+	return open(aead, id, value)
+}
+
+// sealRow encrypts the content, images and tool calls of the row if
+// encryption is enabled. Values that didn't change are kept as they are
+// stored, so saving a conversation again doesn't rewrite its messages.
+func (c *convoDB) sealRow(row *messageRow, prev *messageRow) error {
+	sealValue := func(value string, prev *string) (string, error) {
+		if prev != nil {
+			if plain, err := c.openValue(row.ConversationID, *prev); err == nil && plain == value {
+				return *prev, nil
+			}
+		}
+		if !c.encrypt {
+			return value, nil
+		}
+		aead, err := c.cipher(true)
+		if err != nil {
+			return "", err
+		}
+		return seal(aead, row.ConversationID, value)
+	}
+
+	var prevContent, prevImages, prevToolCalls *string
+	if prev != nil {
+		prevContent, prevImages, prevToolCalls = &prev.Content, prev.Images, prev.ToolCalls
+	}
+	var err error
+	if row.Content, err = sealValue(row.Content, prevContent); err != nil {
+		return err
+	}
+	if row.Images != nil {
+		if *row.Images, err = sealValue(*row.Images, prevImages); err != nil {
+			return err
+		}
+	}
+	if row.ToolCalls != nil {
+		if *row.ToolCalls, err = sealValue(*row.ToolCalls, prevToolCalls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openRow decrypts the content, images and tool calls of the row.
+func (c *convoDB) openRow(row *messageRow) error {
+	var err error
+	if row.Content, err = c.openValue(row.ConversationID, row.Content); err != nil {
+		return err
+	}
+	if row.Images != nil {
+		if *row.Images, err = c.openValue(row.ConversationID, *row.Images); err != nil {
+			return err
+		}
+	}
+	if row.ToolCalls != nil {
+		if *row.ToolCalls, err = c.openValue(row.ConversationID, *row.ToolCalls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountEncrypted returns how many messages and alternatives are encrypted.
+func (c *convoDB) CountEncrypted() (int, error) {
+	var count int
+	if err := c.db.Get(&count, `
+		SELECT
+		  (
+		    SELECT count(*) FROM messages WHERE content GLOB '`+encryptedPrefix+`*'
+		  ) + (
+		    SELECT count(*) FROM message_alternatives WHERE content GLOB '`+encryptedPrefix+`*'
+		  )
+	`); err != nil {
+		return 0, fmt.Errorf("CountEncrypted: %w", err)
+	}
+	return count, nil
+}
+
+// unlockForSave sets up or gets the key before saving messages if they are
+// encrypted, as it can't be saved within the transaction saving them.
+func (c *convoDB) unlockForSave() error {
+	if !c.encrypt {
+		return nil
+	}
+	_, err := c.cipher(true)
+	return err
+}
+
+// Encrypt encrypts the messages and alternatives that aren't yet, setting
+// up the key if needed, and returns how many were encrypted. The search
+// index is rebuilt without them, and the database vacuumed so their content
+// doesn't linger in free pages.
+func (c *convoDB) Encrypt() (int, error) {
+	aead, err := c.cipher(true)
+	if err != nil {
+		return 0, fmt.Errorf("Encrypt: %w", err)
+	}
+	n, err := c.rewriteMessages(nil, func(id, value string) (string, error) {
+		if isEncrypted(value) {
+			return value, nil
+		}
+		return seal(aead, id, value)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Encrypt: %w", err)
+	}
+	if err := c.RebuildSearchIndex(); err != nil {
+		return n, fmt.Errorf("Encrypt: %w", err)
+	}
+	if _, err := c.db.Exec(`VACUUM`); err != nil {
+		return n, fmt.Errorf("Encrypt: %w", err)
+	}
+	return n, nil
+}
+
+// Decrypt decrypts all the messages and alternatives, removes the key, and
+// returns how many were decrypted. They are searchable again.
+func (c *convoDB) Decrypt() (int, error) {
+	count, err := c.CountEncrypted()
+	if err != nil {
+		return 0, fmt.Errorf("Decrypt: %w", err)
+	}
+	var aead cipher.AEAD
+	if count > 0 {
+		if aead, err = c.cipher(false); err != nil {
+			return 0, fmt.Errorf("Decrypt: %w", err)
+		}
+	}
+	n, err := c.rewriteMessages(
+		func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(`DELETE FROM encryption_key`)
+			return err //nolint:wrapcheck
+		},
+		func(id, value string) (string, error) {
+			if !isEncrypted(value) {
+				return value, nil
+			}
+			if aead == nil {
+				return "", errNoKey
+			}
+			return open(aead, id, value)
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Decrypt: %w", err)
+	}
+	c.aead = nil
+	if err := c.RebuildSearchIndex(); err != nil {
+		return n, fmt.Errorf("Decrypt: %w", err)
+	}
+	return n, nil
+}
+
+// Rekey encrypts the messages and alternatives again with the key derived
+// with the given salt, which replaces the current key, and returns how many
+// were encrypted again.
+func (c *convoDB) Rekey(salt, key []byte) (int, error) {
+	current, err := c.cipher(false)
+	if err != nil {
+		return 0, fmt.Errorf("Rekey: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return 0, fmt.Errorf("Rekey: %w", err)
+	}
+	check, err := seal(aead, "", keyCheck)
+	if err != nil {
+		return 0, fmt.Errorf("Rekey: %w", err)
+	}
+	n, err := c.rewriteMessages(
+		func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(tx.Rebind(`
+				UPDATE encryption_key
+				SET
+				  salt = ?,
+				  check_value = ?,
+				  created_at = strftime ('%Y-%m-%d %H:%M:%f', 'now')
+			`), salt, check)
+			return err //nolint:wrapcheck
+		},
+		func(id, value string) (string, error) {
+			if !isEncrypted(value) {
+				return value, nil
+			}
+			plain, err := open(current, id, value)
+			if err != nil {
+				return "", err
+			}
+			return seal(aead, id, plain)
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Rekey: %w", err)
+	}
+	c.aead = aead
+	if _, err := c.db.Exec(`VACUUM`); err != nil {
+		return n, fmt.Errorf("Rekey: %w", err)
+	}
+	return n, nil
+}
+
+// encryptedRow is a message or alternative being encrypted or decrypted.
+type encryptedRow struct {
+	RowID          int64   `db:"rowid"`
+	ConversationID string  `db:"conversation_id"`
+	Content        string  `db:"content"`
+	Images         *string `db:"images"`
+	ToolCalls      *string `db:"tool_calls"`
+}
+
+// rewriteMessages replaces the content, images and tool calls of all the
+// messages and alternatives with the result of fn in a single transaction,
+// which also runs before, and returns how many rows changed.
+func (c *convoDB) rewriteMessages(before func(tx *sqlx.Tx) error, fn func(id, value string) (string, error)) (int, error) {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if before != nil {
+		if err := before(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	var changed int
+	for _, table := range []string{"messages", "message_alternatives"} {
+		var rows []encryptedRow
+		if err := tx.Select(&rows, `
+			SELECT
+			  rowid, conversation_id, content, images, tool_calls
+			FROM
+			  `+table); err != nil {
+			return 0, err //nolint:wrapcheck
+		}
+		for _, row := range rows {
+			updated := row
+			if updated.Content, err = fn(row.ConversationID, row.Content); err != nil {
+				return 0, err
+			}
+			if row.Images != nil {
+				if updated.Images, err = rewriteValue(fn, row.ConversationID, *row.Images); err != nil {
+					return 0, err
+				}
+			}
+			if row.ToolCalls != nil {
+				if updated.ToolCalls, err = rewriteValue(fn, row.ConversationID, *row.ToolCalls); err != nil {
+					return 0, err
+				}
+			}
+			if updated.Content == row.Content && equalValues(updated.Images, row.Images) && equalValues(updated.ToolCalls, row.ToolCalls) {
+				continue
+			}
+			if _, err := tx.Exec(tx.Rebind(`
+				UPDATE `+table+`
+				SET
+				  content = ?,
+				  images = ?,
+				  tool_calls = ?
+				WHERE
+				  rowid = ?
+			`), updated.Content, updated.Images, updated.ToolCalls, row.RowID); err != nil {
+				return 0, err //nolint:wrapcheck
+			}
+			changed++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	return changed, nil
+}
+
+func rewriteValue(fn func(id, value string) (string, error), id, value string) (*string, error) {
+	result, err := fn(id, value)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func equalValues(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey returns the keys derived from the given passphrase, as
+// getEncryptionKey does without asking for it.
+func testKey(passphrase string) encryptionKey {
+	return func(salt []byte, isNew bool, check func(key []byte) bool) ([]byte, error) {
+		key := deriveKey(passphrase, salt)
+		if !isNew && !check(key) {
+			return nil, errWrongKey
+		}
+		return key, nil
+	}
+}
+
+// reopen opens the database of c again, unlocked with key.
+func reopen(t *testing.T, c *convoDB, key encryptionKey) *convoDB {
+	t.Helper()
+	other, err := openDB(c.path)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, other.Close()) })
+	other.key = key
+	return other
+}
+
+func TestEncryption(t *testing.T) {
+	c := testDB(t)
+	c.key = testKey("secret")
+	id := newConversationID()
+	messages := []proto.Message{
+		{Role: proto.RoleUser, Content: "what is in the picture?", Images: [][]byte{[]byte("png")}},
+		{
+			Role: proto.RoleAssistant,
+			ToolCalls: []proto.ToolCall{{
+				ID:       "1",
+				Function: proto.Function{Name: "builtin_read_file", Arguments: []byte(`{"path":"notes.txt"}`)},
+			}},
+		},
+		{Role: proto.RoleTool, Content: "a secret recipe"},
+		{Role: proto.RoleAssistant, Content: "a cake"},
+	}
+	require.NoError(t, c.Save(id, "picture", "ollama", "llama3", messages))
+	requireSearch := func(t *testing.T, c *convoDB, query string, n int) {
+		t.Helper()
+		hits, err := c.Search(query, searchLimit)
+		require.NoError(t, err)
+		require.Len(t, hits, n)
+	}
+	requireSearch(t, c, "recipe", 1)
+
+	n, err := c.Encrypt()
+	require.NoError(t, err)
+	require.Equal(t, len(messages), n)
+	count, err := c.CountEncrypted()
+	require.NoError(t, err)
+	require.Equal(t, n, count)
+	for _, row := range storedMessages(t, c, id) {
+		require.True(t, isEncrypted(row.Content), "message %d", row.Index)
+		if row.Images != nil {
+			require.True(t, isEncrypted(*row.Images), "message %d", row.Index)
+		}
+		if row.ToolCalls != nil {
+			require.True(t, isEncrypted(*row.ToolCalls), "message %d", row.Index)
+		}
+	}
+	got, err := c.Messages(id)
+	require.NoError(t, err)
+	require.Equal(t, messages, got)
+	requireSearch(t, c, "recipe", 0)
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := reopen(t, c, testKey("guess")).Messages(id)
+		require.ErrorIs(t, err, errWrongKey)
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		_, err := reopen(t, c, nil).Messages(id)
+		require.ErrorIs(t, err, errNoKey)
+	})
+
+	t.Run("values are bound to their conversation", func(t *testing.T) {
+		other := newConversationID()
+		row := storedMessages(t, c, id)[3]
+		_, err := c.db.Exec(c.db.Rebind(`
+			INSERT INTO
+			  messages (conversation_id, idx, role, content)
+			VALUES
+			  (?, 0, ?, ?)
+		`), other, row.Role, row.Content)
+		require.NoError(t, err)
+		_, err = c.Messages(other)
+		require.ErrorContains(t, err, "could not decrypt")
+		require.NoError(t, c.Delete(other))
+	})
+
+	t.Run("saves encrypted", func(t *testing.T) {
+		c.encrypt = true
+		t.Cleanup(func() { c.encrypt = false })
+		added := append(messages[:4:4], proto.Message{Role: proto.RoleUser, Content: "and the recipe?"})
+		require.NoError(t, c.Save(id, "picture", "ollama", "llama3", added))
+		rows := storedMessages(t, c, id)
+		require.True(t, isEncrypted(rows[4].Content))
+		got, err := reopen(t, c, testKey("secret")).Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, added, got)
+		require.NoError(t, c.Save(id, "picture", "ollama", "llama3", messages))
+	})
+
+	t.Run("rekey", func(t *testing.T) {
+		salt := make([]byte, saltLen)
+		_, err := rand.Read(salt)
+		require.NoError(t, err)
+		n, err := c.Rekey(salt, deriveKey("rotated", salt))
+		require.NoError(t, err)
+		require.Equal(t, len(messages), n)
+
+		got, err := c.Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, messages, got)
+		_, err = reopen(t, c, testKey("secret")).Messages(id)
+		require.ErrorIs(t, err, errWrongKey)
+		got, err = reopen(t, c, testKey("rotated")).Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, messages, got)
+	})
+
+	t.Run("decrypt", func(t *testing.T) {
+		plain := reopen(t, c, testKey("rotated"))
+		n, err := plain.Decrypt()
+		require.NoError(t, err)
+		require.Equal(t, len(messages), n)
+		count, err := plain.CountEncrypted()
+		require.NoError(t, err)
+		require.Zero(t, count)
+		require.Zero(t, countKeys(t, plain))
+		requireSearch(t, plain, "recipe", 1)
+
+		got, err := reopen(t, c, nil).Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, messages, got)
+	})
+}
+
+func countKeys(t *testing.T, c *convoDB) int {
+	t.Helper()
+	var count int
+	require.NoError(t, c.db.Get(&count, `SELECT count(*) FROM encryption_key`))
+	return count
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/cache"
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/jmoiron/sqlx"
+)
+
+// migrateMessages creates the messages table, which holds the messages of
+// every conversation in order.
+func migrateMessages(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS messages (
+		    conversation_id string NOT NULL,
+		    idx integer NOT NULL,
+		    role string NOT NULL,
+		    content string NOT NULL DEFAULT '',
+		    images string,
+		    tool_calls string,
+		    model string,
+		    metrics string,
+		    created_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+		    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+		    PRIMARY KEY (conversation_id, idx),
+		    CHECK (role <> '')
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// messageRow is a message in the database.
+type messageRow struct {
+	ConversationID string    `db:"conversation_id"`
+	Index          int       `db:"idx"`
+	Role           string    `db:"role"`
+	Content        string    `db:"content"`
+	Images         *string   `db:"images"`
+	ToolCalls      *string   `db:"tool_calls"`
+	Model          *string   `db:"model"`
+	Metrics        *string   `db:"metrics"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// storedToolCall is how tool calls are stored, with the arguments kept as
+// JSON so they can be queried.
+type storedToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Duration  time.Duration   `json:"duration_ns,omitempty"`
+}
+
+func newMessageRow(id string, idx int, model string, msg proto.Message) (messageRow, error) {
+	row := messageRow{
+		ConversationID: id,
+		Index:          idx,
+		Role:           msg.Role,
+		Content:        msg.Content,
+	}
+	if msg.Role == proto.RoleAssistant && model != "" {
+		row.Model = &model
+	}
+	if len(msg.Images) > 0 {
+		bts, err := json.Marshal(msg.Images)
+		if err != nil {
+			return row, fmt.Errorf("could not encode images: %w", err)
+		}
+		row.Images = ptr(string(bts))
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]storedToolCall, 0, len(msg.ToolCalls))
+		for _, call := range msg.ToolCalls {
+			args := json.RawMessage(call.Function.Arguments)
+			if len(args) > 0 && !json.Valid(args) {
+				args, _ = json.Marshal(string(call.Function.Arguments))
+			}
+			calls = append(calls, storedToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: args,
+				IsError:   call.IsError,
+				Duration:  call.Duration,
+			})
+		}
+		bts, err := json.Marshal(calls)
+		if err != nil {
+			return row, fmt.Errorf("could not encode tool calls: %w", err)
+		}
+		row.ToolCalls = ptr(string(bts))
+	}
+	if msg.Metrics != nil {
+		bts, err := json.Marshal(msg.Metrics)
+		if err != nil {
+			return row, fmt.Errorf("could not encode metrics: %w", err)
+		}
+		row.Metrics = ptr(string(bts))
+	}
+	return row, nil
+}
+
+func (r messageRow) message() (proto.Message, error) {
+	msg := proto.Message{
+		Role:    r.Role,
+		Content: r.Content,
+	}
+	if r.Images != nil {
+		if err := json.Unmarshal([]byte(*r.Images), &msg.Images); err != nil {
+			return msg, fmt.Errorf("could not decode images: %w", err)
+		}
+	}
+	if r.ToolCalls != nil {
+		var calls []storedToolCall
+		if err := json.Unmarshal([]byte(*r.ToolCalls), &calls); err != nil {
+			return msg, fmt.Errorf("could not decode tool calls: %w", err)
+		}
+		for _, call := range calls {
+			args := []byte(call.Arguments)
+			var s string
+			if json.Unmarshal(call.Arguments, &s) == nil {
+				args = []byte(s)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, proto.ToolCall{
+				ID: call.ID,
+				Function: proto.Function{
+					Name:      call.Name,
+					Arguments: args,
+				},
+				IsError:  call.IsError,
+				Duration: call.Duration,
+			})
+		}
+	}
+	if r.Metrics != nil {
+		msg.Metrics = &proto.Metrics{}
+		if err := json.Unmarshal([]byte(*r.Metrics), msg.Metrics); err != nil {
+			return msg, fmt.Errorf("could not decode metrics: %w", err)
+		}
+	}
+	return msg, nil
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// saveMessages replaces the messages of the given conversation. Messages
+// that didn't change keep their timestamps and model, new assistant messages
+// are attributed to the given model.
+func (c *convoDB) saveMessages(tx *sqlx.Tx, id, model string, messages []proto.Message) error {
+	var stored []messageRow
+	if err := tx.Select(&stored, tx.Rebind(`
+		SELECT
+		  *
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		ORDER BY
+		  idx
+	`), id); err != nil {
+		return fmt.Errorf("could not save messages: %w", err)
+	}
+	for i, msg := range messages {
+		row, err := newMessageRow(id, i, model, msg)
+		if err != nil {
+			return err
+		}
+		var prev *messageRow
+		if i < len(stored) && stored[i].Index == i {
+			prev = &stored[i]
+		}
+		if err := c.sealRow(&row, prev); err != nil {
+			return fmt.Errorf("could not save message %d: %w", i, err)
+		}
+		if _, err := tx.Exec(tx.Rebind(`
+			INSERT INTO
+			  messages (conversation_id, idx, role, content, images, tool_calls, model, metrics)
+			VALUES
+			  (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (conversation_id, idx) DO UPDATE
+			SET
+			  role = excluded.role,
+			  content = excluded.content,
+			  images = excluded.images,
+			  tool_calls = excluded.tool_calls,
+			  model = excluded.model,
+			  metrics = excluded.metrics,
+			  updated_at = strftime ('%Y-%m-%d %H:%M:%f', 'now')
+			WHERE
+			  role IS NOT excluded.role
+			  OR content IS NOT excluded.content
+			  OR images IS NOT excluded.images
+			  OR tool_calls IS NOT excluded.tool_calls
+		`), row.ConversationID, row.Index, row.Role, row.Content, row.Images, row.ToolCalls, row.Model, row.Metrics); err != nil {
+			return fmt.Errorf("could not save message %d: %w", i, err)
+		}
+	}
+	if _, err := tx.Exec(tx.Rebind(`
+		DELETE FROM messages
+		WHERE
+		  conversation_id = ?
+		  AND idx >= ?
+	`), id, len(messages)); err != nil {
+		return fmt.Errorf("could not save messages: %w", err)
+	}
+	return nil
+}
+
+// Messages returns the messages of the given conversation, errNoMessages if
+// it has none stored.
+func (c *convoDB) Messages(id string) ([]proto.Message, error) {
+	var rows []messageRow
+	if err := c.db.Select(&rows, c.db.Rebind(`
+		SELECT
+		  *
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		ORDER BY
+		  idx
+	`), id); err != nil {
+		return nil, fmt.Errorf("Messages: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("Messages: %w: %s", errNoMessages, id)
+	}
+	messages := make([]proto.Message, 0, len(rows))
+	for _, row := range rows {
+		if err := c.openRow(&row); err != nil {
+			return nil, fmt.Errorf("Messages: %w", err)
+		}
+		msg, err := row.message()
+		if err != nil {
+			return nil, fmt.Errorf("Messages: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// importGobConversations moves the messages of conversations saved before
+// they were stored in the database from their gob files into it. Each file
+// is removed once its messages are saved, so this only happens once.
+func importGobConversations(c *convoDB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, string(cache.ConversationCache), "*.gob"))
+	if err != nil || len(files) == 0 {
+		return err //nolint:wrapcheck
+	}
+	legacy, err := cache.NewConversations(dir)
+	if err != nil {
+		return fmt.Errorf("could not open the conversation cache: %w", err)
+	}
+
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".gob")
+		var messages []proto.Message
+		if err := legacy.Read(id, &messages); err != nil {
+			// leave unreadable files alone, they might be salvaged by hand.
+			continue
+		}
+
+		var convo Conversation
+		if err := c.db.Get(&convo, c.db.Rebind(`
+			SELECT
+			  *
+			FROM
+			  conversations
+			WHERE
+			  id = ?
+		`), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// the conversation was deleted, only its file was left.
+				_ = os.Remove(file)
+				continue
+			}
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+
+		var model string
+		if convo.Model != nil {
+			model = *convo.Model
+		}
+		if err := c.unlockForSave(); err != nil {
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+		tx, err := c.db.Beginx()
+		if err != nil {
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+		if err := c.saveMessages(tx, id, model, messages); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+		if err := indexMessages(tx, id); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("could not import %s: %w", id, err)
+		}
+		_ = os.Remove(file)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB opens a migrated database in a temporary file.
+func testDB(t *testing.T) *convoDB {
+	t.Helper()
+	c, err := openDB(filepath.Join(t.TempDir(), "mods.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, c.Close()) })
+	_, _, err = c.Migrate()
+	require.NoError(t, err)
+	return c
+}
+
+func storedMessages(t *testing.T, c *convoDB, id string) []messageRow {
+	t.Helper()
+	var rows []messageRow
+	require.NoError(t, c.db.Select(&rows, c.db.Rebind(`
+		SELECT
+		  *
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		ORDER BY
+		  idx
+	`), id))
+	return rows
+}
+
+func countRows(t *testing.T, c *convoDB, table, id string) int {
+	t.Helper()
+	var count int
+	require.NoError(t, c.db.Get(&count, c.db.Rebind(`
+		SELECT
+		  count(*)
+		FROM
+		  `+table+`
+		WHERE
+		  conversation_id = ?
+	`), id))
+	return count
+}
+
+func TestSaveMessages(t *testing.T) {
+	c := testDB(t)
+	id := newConversationID()
+	messages := []proto.Message{
+		{Role: proto.RoleSystem, Content: "be brief"},
+		{Role: proto.RoleUser, Content: "list the files"},
+		{
+			Role: proto.RoleAssistant,
+			ToolCalls: []proto.ToolCall{{
+				ID: "1",
+				Function: proto.Function{
+					Name:      "builtin_list_directory",
+					Arguments: []byte(`{"path":"."}`),
+				},
+				Duration: time.Second,
+			}},
+		},
+		{Role: proto.RoleTool, Content: "main.go"},
+		{
+			Role:    proto.RoleAssistant,
+			Content: "There is main.go.",
+			Metrics: &proto.Metrics{PromptTokens: 10, CompletionTokens: 4},
+		},
+	}
+	require.NoError(t, c.Save(id, "files", "ollama", "llama3", messages))
+
+	got, err := c.Messages(id)
+	require.NoError(t, err)
+	require.Equal(t, messages, got)
+	first := storedMessages(t, c, id)
+	require.Nil(t, first[1].Model)
+	require.Equal(t, "llama3", *first[4].Model)
+
+	t.Run("keeps unchanged messages", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+		changed := append(messages[:4:4], proto.Message{Role: proto.RoleAssistant, Content: "Only main.go."})
+		require.NoError(t, c.Save(id, "files", "ollama", "qwen3", changed))
+
+		rows := storedMessages(t, c, id)
+		require.Len(t, rows, len(changed))
+		for i := range 4 {
+			require.Equal(t, first[i].UpdatedAt, rows[i].UpdatedAt, "message %d", i)
+			require.Equal(t, first[i].Model, rows[i].Model, "message %d", i)
+		}
+		require.True(t, rows[4].UpdatedAt.After(first[4].UpdatedAt))
+		require.Equal(t, first[4].CreatedAt, rows[4].CreatedAt)
+		require.Equal(t, "qwen3", *rows[4].Model)
+		require.Equal(t, "Only main.go.", rows[4].Content)
+	})
+
+	t.Run("removes dropped messages", func(t *testing.T) {
+		require.NoError(t, c.Save(id, "files", "ollama", "qwen3", messages[:2]))
+		got, err := c.Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, messages[:2], got)
+	})
+
+	t.Run("nil leaves the messages", func(t *testing.T) {
+		require.NoError(t, c.Save(id, "renamed", "ollama", "qwen3", nil))
+		got, err := c.Messages(id)
+		require.NoError(t, err)
+		require.Equal(t, messages[:2], got)
+	})
+
+	t.Run("no messages", func(t *testing.T) {
+		_, err := c.Messages(newConversationID())
+		require.ErrorIs(t, err, errNoMessages)
+	})
+}
+
+func TestForkAndDelete(t *testing.T) {
+	c := testDB(t)
+	id := newConversationID()
+	messages := []proto.Message{
+		{Role: proto.RoleUser, Content: "first question"},
+		{Role: proto.RoleAssistant, Content: "first answer"},
+		{Role: proto.RoleUser, Content: "second question"},
+		{Role: proto.RoleAssistant, Content: "second answer"},
+	}
+	require.NoError(t, c.Save(id, "parent", "ollama", "llama3", messages))
+	_, err := c.SaveTurn(savedTurn{
+		ID:           id,
+		Title:        "parent",
+		TitleSource:  titleFromUser,
+		API:          "ollama",
+		Model:        "llama3",
+		Messages:     append(messages[:3:3], proto.Message{Role: proto.RoleAssistant, Content: "other answer"}),
+		Tags:         []string{"work"},
+		Replaced:     true,
+		ReplacedFrom: 2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.SetPinned(id, true))
+	require.NoError(t, c.SetNote(id, "keep"))
+
+	parent, err := c.Find(id)
+	require.NoError(t, err)
+	forkID := newConversationID()
+	require.NoError(t, c.Fork(*parent, forkID, "fork", 1, messages[:2]))
+
+	fork, err := c.Find(forkID)
+	require.NoError(t, err)
+	require.Equal(t, id, *fork.ParentID)
+	require.Equal(t, 1, *fork.ForkTurn)
+	require.Equal(t, "llama3", *fork.Model)
+	got, err := c.Messages(forkID)
+	require.NoError(t, err)
+	require.Equal(t, messages[:2], got)
+	hits, err := c.Search("first", searchLimit)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+
+	tables := []string{"messages", "messages_fts", "message_alternatives", "conversation_tags", "conversation_meta"}
+	for _, table := range tables {
+		require.NotZero(t, countRows(t, c, table, id), table)
+	}
+	require.NoError(t, c.Delete(id))
+	for _, table := range tables {
+		require.Zero(t, countRows(t, c, table, id), table)
+	}
+	_, err = c.Find(id)
+	require.ErrorIs(t, err, errNoMatches)
+	_, err = c.Messages(id)
+	require.ErrorIs(t, err, errNoMessages)
+
+	// the fork outlives its parent.
+	got, err = c.Messages(forkID)
+	require.NoError(t, err)
+	require.Equal(t, messages[:2], got)
+	hits, err = c.Search("first", searchLimit)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, forkID, hits[0].ID)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migration changes the schema of the database. Each migration is applied
+// in a transaction, and recorded in the schema_version table.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sqlx.Tx) error
+}
+
+// migrations are applied in order. New migrations are added at the end with
+// the next version, and migrations never change once released.
+//
+// The migrations up to version 9 predate the schema_version table, and
+// databases created before may already have some of their tables and
+// columns, so they only create what is missing.
+var migrations = []migration{
+	{1, "Create the conversations table", migrateConversations},
+	{2, "Add the model and api columns", addColumns("model string", "api string")},
+	{3, "Create the messages table", migrateMessages},
+	{4, "Create the full-text index of the messages", migrateSearch},
+	{5, "Add the parent_id and fork_turn columns", addColumns("parent_id string", "fork_turn integer")},
+	{6, "Create the message_alternatives table", migrateAlternatives},
+	{7, "Create the conversation_tags and conversation_meta tables", migrateTags},
+	{8, "Add the title_source column", addColumns("title_source string")},
+	{9, "Create the encryption_key table", migrateEncryption},
+}
+
+// latestSchemaVersion is the version of the database once migrated.
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+var errNewerSchema = errors.New("the database was migrated by a newer version of oi")
+
+// migrateConversations creates the conversations table.
+func migrateConversations(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS conversations (
+		    id string NOT NULL PRIMARY KEY,
+		    title string NOT NULL,
+		    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+		    CHECK (id <> ''),
+		    CHECK (title <> '')
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if _, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id)
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if _, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title)
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// addColumns adds the given columns to the conversations table, each
+// defined by its name and type.
+func addColumns(columns ...string) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		for _, column := range columns {
+			name, _, _ := strings.Cut(column, " ")
+			if hasColumn(tx, name) {
+				continue
+			}
+			if _, err := tx.Exec(`ALTER TABLE conversations ADD COLUMN ` + column); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+		return nil
+	}
+}
+
+// appliedMigration is a migration recorded in the schema_version table.
+type appliedMigration struct {
+	Version     int       `db:"version"`
+	Description string    `db:"description"`
+	AppliedAt   time.Time `db:"applied_at"`
+}
+
+func (c *convoDB) hasTable(name string) (bool, error) {
+	var count int
+	if err := c.db.Get(&count, c.db.Rebind(`
+		SELECT count(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name = ?
+	`), name); err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	return count > 0, nil
+}
+
+// AppliedMigrations returns the migrations applied to the database, in
+// order.
+func (c *convoDB) AppliedMigrations() ([]appliedMigration, error) {
+	exists, err := c.hasTable("schema_version")
+	if err != nil {
+		return nil, fmt.Errorf("AppliedMigrations: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	var applied []appliedMigration
+	if err := c.db.Select(&applied, `
+		SELECT
+		  version,
+		  description,
+		  applied_at
+		FROM
+		  schema_version
+		ORDER BY
+		  version
+	`); err != nil {
+		return nil, fmt.Errorf("AppliedMigrations: %w", err)
+	}
+	return applied, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the
+// database, 0 if none was.
+func (c *convoDB) SchemaVersion() (int, error) {
+	applied, err := c.AppliedMigrations()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// PendingMigrations returns the migrations not applied to the database yet.
+func (c *convoDB) PendingMigrations() ([]migration, error) {
+	version, err := c.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > latestSchemaVersion() {
+		return nil, fmt.Errorf("%w: version %d, this version of oi knows up to %d", errNewerSchema, version, latestSchemaVersion())
+	}
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations, after backing up the database
+// unless it is new. It returns the migrations applied and the path of the
+// backup, if any.
+func (c *convoDB) Migrate() ([]migration, string, error) {
+	pending, err := c.PendingMigrations()
+	if err != nil || len(pending) == 0 {
+		return nil, "", err
+	}
+
+	var backup string
+	existing, err := c.hasTable("conversations")
+	if err != nil {
+		return nil, "", fmt.Errorf("Migrate: %w", err)
+	}
+	if existing && c.path != "" {
+		backup = fmt.Sprintf("%s.v%d.bak", c.path, pending[0].version-1)
+		if err := c.backup(backup); err != nil {
+			return nil, "", fmt.Errorf("Migrate: could not back up the database: %w", err)
+		}
+	}
+
+	if _, err := c.db.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS schema_version (
+		    version integer NOT NULL PRIMARY KEY,
+		    description string NOT NULL,
+		    applied_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now'))
+		  )
+	`); err != nil {
+		return nil, backup, fmt.Errorf("Migrate: %w", err)
+	}
+	var migrated []migration
+	for _, m := range pending {
+		applied, err := c.apply(m)
+		if err != nil {
+			return migrated, backup, fmt.Errorf("Migrate: migration %d: %w", m.version, err)
+		}
+		if applied {
+			migrated = append(migrated, m)
+		}
+	}
+	return migrated, backup, nil
+}
+
+// apply applies the migration, unless another process applied it since the
+// pending migrations were listed. It returns whether it was applied.
+func (c *convoDB) apply(m migration) (bool, error) {
+	// transactions begin immediately, holding the write lock until the
+	// migration is recorded.
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var version int
+	if err := tx.Get(&version, `
+		SELECT
+		  coalesce(max(version), 0)
+		FROM
+		  schema_version
+	`); err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	if version >= m.version {
+		return false, nil
+	}
+
+	if err := m.up(tx); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(tx.Rebind(`
+		INSERT INTO
+		  schema_version (version, description)
+		VALUES
+		  (?, ?)
+	`), m.version, m.description); err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	return true, tx.Commit() //nolint:wrapcheck
+}
+
+// backup copies the database to the given path, unless a backup of the same
+// version is already there from a migration that failed, or from another
+// process migrating at the same time.
+func (c *convoDB) backup(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if _, err := c.db.Exec(`VACUUM INTO ?`, path); err != nil {
+		if _, serr := os.Stat(path); serr == nil {
+			return nil
+		}
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database, reclaiming the space of deleted
+// conversations.
+func (c *convoDB) Vacuum() error {
+	if _, err := c.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("Vacuum: %w", err)
+	}
+	return nil
+}
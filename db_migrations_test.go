@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// tableColumns returns the columns of every table of the database, leaving
+// out the internal tables of SQLite and the full-text index.
+func tableColumns(t *testing.T, c *convoDB) map[string][]string {
+	t.Helper()
+	var tables []string
+	require.NoError(t, c.db.Select(&tables, `
+		SELECT
+		  name
+		FROM
+		  sqlite_master
+		WHERE
+		  type = 'table'
+		  AND name NOT LIKE 'sqlite_%'
+		  AND name NOT LIKE 'messages_fts_%'
+	`))
+	columns := map[string][]string{}
+	for _, table := range tables {
+		var names []string
+		require.NoError(t, c.db.Select(&names, `SELECT name FROM pragma_table_info(?) ORDER BY cid`, table))
+		columns[table] = names
+	}
+	return columns
+}
+
+// requireMigrated checks the database is at the latest version, with the
+// same schema as a new one, and that its conversations can be used.
+func requireMigrated(t *testing.T, c *convoDB) {
+	t.Helper()
+	version, err := c.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, latestSchemaVersion(), version)
+	pending, err := c.PendingMigrations()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	require.Equal(t, tableColumns(t, testDB(t)), tableColumns(t, c))
+
+	id := newConversationID()
+	messages := []proto.Message{
+		{Role: proto.RoleUser, Content: "migrated question"},
+		{Role: proto.RoleAssistant, Content: "migrated answer"},
+	}
+	require.NoError(t, c.Save(id, "migrated", "ollama", "llama3", messages))
+	require.NoError(t, c.AddTags(id, []string{"new"}))
+	got, err := c.Messages(id)
+	require.NoError(t, err)
+	require.Equal(t, messages, got)
+	hits, err := c.Search("migrated", searchLimit)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("new database", func(t *testing.T) {
+		c, err := openDB(filepath.Join(t.TempDir(), "mods.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, c.Close()) })
+
+		migrated, backup, err := c.Migrate()
+		require.NoError(t, err)
+		require.Len(t, migrated, len(migrations))
+		require.Empty(t, backup, "new databases aren't backed up")
+		requireMigrated(t, c)
+
+		migrated, backup, err = c.Migrate()
+		require.NoError(t, err)
+		require.Empty(t, migrated)
+		require.Empty(t, backup)
+	})
+
+	t.Run("baseline schema", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mods.db")
+		c, err := openDB(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, c.Close()) })
+		// the schema of the releases predating the migrations.
+		_, err = c.db.Exec(`
+			CREATE TABLE
+			  IF NOT EXISTS conversations (
+			    id string NOT NULL PRIMARY KEY,
+			    title string NOT NULL,
+			    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+			    CHECK (id <> ''),
+			    CHECK (title <> '')
+			  );
+			CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id);
+			CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title);
+			ALTER TABLE conversations ADD COLUMN model string;
+			ALTER TABLE conversations ADD COLUMN api string;
+		`)
+		require.NoError(t, err)
+		id := newConversationID()
+		_, err = c.db.Exec(`INSERT INTO conversations (id, title, model, api) VALUES (?, 'old', 'llama3', 'ollama')`, id)
+		require.NoError(t, err)
+
+		pending, err := c.PendingMigrations()
+		require.NoError(t, err)
+		require.Len(t, pending, len(migrations))
+		migrated, backup, err := c.Migrate()
+		require.NoError(t, err)
+		require.Len(t, migrated, len(migrations))
+		require.Equal(t, path+".v0.bak", backup)
+		require.FileExists(t, backup)
+
+		convo, err := c.Find(id)
+		require.NoError(t, err)
+		require.Equal(t, "old", convo.Title)
+		require.Equal(t, "llama3", *convo.Model)
+		requireMigrated(t, c)
+	})
+
+	all := migrations
+	for v := 1; v < latestSchemaVersion(); v++ {
+		t.Run(fmt.Sprintf("from version %d", v), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mods.db")
+			c, err := openDB(path)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, c.Close()) })
+
+			// an older oi only knew the migrations up to v.
+			migrations = all[:v]
+			_, _, err = c.Migrate()
+			migrations = all
+			require.NoError(t, err)
+			id := newConversationID()
+			_, err = c.db.Exec(`INSERT INTO conversations (id, title) VALUES (?, 'old')`, id)
+			require.NoError(t, err)
+
+			migrated, backup, err := c.Migrate()
+			require.NoError(t, err)
+			require.Len(t, migrated, len(all)-v)
+			require.Equal(t, all[v].version, migrated[0].version)
+			require.Equal(t, fmt.Sprintf("%s.v%d.bak", path, v), backup)
+			require.FileExists(t, backup)
+
+			applied, err := c.AppliedMigrations()
+			require.NoError(t, err)
+			require.Len(t, applied, len(all))
+			for i, m := range applied {
+				require.Equal(t, all[i].version, m.Version)
+				require.Equal(t, all[i].description, m.Description)
+			}
+			_, err = c.Find(id)
+			require.NoError(t, err)
+			requireMigrated(t, c)
+		})
+	}
+
+	t.Run("newer schema", func(t *testing.T) {
+		c := testDB(t)
+		_, err := c.db.Exec(`INSERT INTO schema_version (version, description) VALUES (?, 'from the future')`, latestSchemaVersion()+1)
+		require.NoError(t, err)
+		_, _, err = c.Migrate()
+		require.ErrorIs(t, err, errNewerSchema)
+	})
+
+	t.Run("backup left by a failed migration", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mods.db")
+		c, err := openDB(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, c.Close()) })
+		migrations = all[:1]
+		_, _, err = c.Migrate()
+		migrations = all
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path+".v1.bak", []byte("earlier backup"), 0o600))
+
+		_, backup, err := c.Migrate()
+		require.NoError(t, err)
+		bts, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		require.Equal(t, "earlier backup", string(bts), "the first backup is kept")
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// storedConversation is what the retention policy needs to know about a
+// conversation.
+type storedConversation struct {
+	ID          string    `db:"id"`
+	UpdatedAt   time.Time `db:"updated_at"`
+	Pinned      bool      `db:"pinned"`
+	TitleSource *string   `db:"title_source"`
+	// Size is the size in bytes of the messages and alternatives.
+	Size int64 `db:"size"`
+}
+
+// Stored lists the conversations with their size, newest first.
+func (c *convoDB) Stored() ([]storedConversation, error) {
+	var convos []storedConversation
+	if err := c.db.Select(&convos, `
+		WITH
+		  sizes AS (
+		    SELECT
+		      conversation_id,
+		      sum(
+		        length(CAST(content AS BLOB)) + coalesce(length(images), 0) + coalesce(length(tool_calls), 0) + coalesce(length(metrics), 0)
+		      ) AS size
+		    FROM
+		      (
+		        SELECT
+		          conversation_id, content, images, tool_calls, metrics
+		        FROM
+		          messages
+		        UNION ALL
+		        SELECT
+		          conversation_id, content, images, tool_calls, metrics
+		        FROM
+		          message_alternatives
+		      )
+		    GROUP BY
+		      conversation_id
+		  )
+		SELECT
+		  c.id,
+		  c.updated_at,
+		  coalesce(m.pinned, 0) AS pinned,
+		  c.title_source,
+		  coalesce(s.size, 0) AS size
+		FROM
+		  conversations c
+		  LEFT JOIN conversation_meta m ON m.conversation_id = c.id
+		  LEFT JOIN sizes s ON s.conversation_id = c.id
+		ORDER BY
+		  c.updated_at DESC
+	`); err != nil {
+		return nil, fmt.Errorf("Stored: %w", err)
+	}
+	return convos, nil
+}
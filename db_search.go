@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Markers around the matches in search snippets.
+const (
+	searchMatchStart = "\x02"
+	searchMatchEnd   = "\x03"
+)
+
+// searchLimit is the maximum number of conversations returned by a search.
+const searchLimit = 50
+
+// migrateSearch creates the full-text index of the messages, indexing the
+// existing messages when it is first created.
+func migrateSearch(tx *sqlx.Tx) error {
+	var count int
+	if err := tx.Get(&count, `
+		SELECT count(*)
+		FROM sqlite_master
+		WHERE name = 'messages_fts'
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`
+		CREATE VIRTUAL TABLE
+		  IF NOT EXISTS messages_fts USING fts5 (
+		    content,
+		    conversation_id UNINDEXED,
+		    idx UNINDEXED,
+		    tokenize = 'porter unicode61'
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return rebuildSearchIndex(tx)
+}
+
+// indexMessages updates the full-text index of the given conversation. Only
+// the user, assistant and tool messages are indexed, system prompts are the
+// same in most conversations. Encrypted messages aren't indexed.
+func indexMessages(tx *sqlx.Tx, id string) error {
+	if _, err := tx.Exec(tx.Rebind(`
+		DELETE FROM messages_fts
+		WHERE
+		  conversation_id = ?
+	`), id); err != nil {
+		return fmt.Errorf("could not index messages: %w", err)
+	}
+	if _, err := tx.Exec(tx.Rebind(`
+		INSERT INTO
+		  messages_fts (content, conversation_id, idx)
+		SELECT
+		  content,
+		  conversation_id,
+		  idx
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		  AND role <> 'system'
+		  AND content <> ''
+		  AND content NOT GLOB '`+encryptedPrefix+`*'
+	`), id); err != nil {
+		return fmt.Errorf("could not index messages: %w", err)
+	}
+	return nil
+}
+
+// RebuildSearchIndex indexes all the messages again.
+func (c *convoDB) RebuildSearchIndex() error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("RebuildSearchIndex: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := rebuildSearchIndex(tx); err != nil {
+		return fmt.Errorf("RebuildSearchIndex: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("RebuildSearchIndex: %w", err)
+	}
+	return nil
+}
+
+func rebuildSearchIndex(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`DELETE FROM messages_fts`); err != nil {
+		return fmt.Errorf("could not index messages: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO
+		  messages_fts (content, conversation_id, idx)
+		SELECT
+		  content,
+		  conversation_id,
+		  idx
+		FROM
+		  messages
+		WHERE
+		  role <> 'system'
+		  AND content <> ''
+		  AND content NOT GLOB '` + encryptedPrefix + `*'
+	`); err != nil {
+		return fmt.Errorf("could not index messages: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO messages_fts (messages_fts) VALUES ('optimize')`); err != nil {
+		return fmt.Errorf("could not index messages: %w", err)
+	}
+	return nil
+}
+
+// Search returns the conversations whose messages match the query, best
+// matches first, each with a snippet of its best matching message. A limit
+// of zero returns all of them.
+func (c *convoDB) Search(query string, limit int) ([]Conversation, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	var convos []Conversation
+	if err := c.db.Select(&convos, c.db.Rebind(`
+		WITH
+		  hits AS MATERIALIZED (
+		    SELECT
+		      conversation_id,
+		      snippet (messages_fts, 0, char(2), char(3), '…', 16) AS snippet,
+		      bm25 (messages_fts) AS score
+		    FROM
+		      messages_fts
+		    WHERE
+		      messages_fts MATCH ?
+		  ),
+		  best AS (
+		    SELECT
+		      conversation_id,
+		      snippet,
+		      min(score) AS score
+		    FROM
+		      hits
+		    GROUP BY
+		      conversation_id
+		  )
+		SELECT
+		  c.*,
+		  best.snippet,`+conversationMeta+`
+		FROM
+		  best
+		  JOIN conversations c ON c.id = best.conversation_id`+conversationMetaJoin+`
+		ORDER BY
+		  best.score
+		LIMIT
+		  ?
+	`), searchQuery(query), limit); err != nil {
+		return nil, fmt.Errorf("Search: %w", handleSqliteErr(err))
+	}
+	return convos, nil
+}
+
+// searchQuery turns the user input into a FTS5 query matching all of its
+// words. Words are quoted so punctuation isn't taken as query syntax, and a
+// trailing * still matches prefixes.
+func searchQuery(in string) string {
+	words := strings.Fields(in)
+	for i, word := range words {
+		var prefix bool
+		word, prefix = strings.CutSuffix(word, "*")
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			words[i] += "*"
+		}
+	}
+	return strings.Join(words, " ")
+}
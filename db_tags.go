@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrateTags creates the conversation_tags table, and the
+// conversation_meta one which holds whether a conversation is pinned and its
+// note.
+func migrateTags(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS conversation_tags (
+		    conversation_id string NOT NULL,
+		    tag string NOT NULL,
+		    PRIMARY KEY (conversation_id, tag),
+		    CHECK (tag <> '')
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if _, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_tags_tag ON conversation_tags (tag)
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if _, err := tx.Exec(`
+		CREATE TABLE
+		  IF NOT EXISTS conversation_meta (
+		    conversation_id string NOT NULL PRIMARY KEY,
+		    pinned boolean NOT NULL DEFAULT 0,
+		    note string
+		  )
+	`); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// conversationMeta selects the pin, note and comma separated tags of the
+// conversation c, joined with conversationMetaJoin.
+const (
+	conversationMeta = `
+		coalesce(m.pinned, 0) AS pinned,
+		m.note,
+		(
+		  SELECT
+		    group_concat(tag, ',')
+		  FROM
+		    (
+		      SELECT
+		        tag
+		      FROM
+		        conversation_tags t
+		      WHERE
+		        t.conversation_id = c.id
+		      ORDER BY
+		        tag
+		    )
+		) AS tags`
+	conversationMetaJoin = `
+		LEFT JOIN conversation_meta m ON m.conversation_id = c.id`
+)
+
+// TagList returns the tags of the conversation.
+func (c Conversation) TagList() []string {
+	if c.Tags == nil || *c.Tags == "" {
+		return nil
+	}
+	return strings.Split(*c.Tags, ",")
+}
+
+// AddTags tags the conversation, ignoring the tags it already has.
+func (c *convoDB) AddTags(id string, tags []string) error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("AddTags: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := addTags(tx, id, tags); err != nil {
+		return fmt.Errorf("AddTags: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("AddTags: %w", err)
+	}
+	return nil
+}
+
+func addTags(tx *sqlx.Tx, id string, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec(tx.Rebind(`
+			INSERT OR IGNORE INTO
+			  conversation_tags (conversation_id, tag)
+			VALUES
+			  (?, ?)
+		`), id, tag); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+// RemoveTags removes the tags from the conversation.
+func (c *convoDB) RemoveTags(id string, tags []string) error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("RemoveTags: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(tx.Rebind(`
+			DELETE FROM conversation_tags
+			WHERE
+			  conversation_id = ?
+			  AND tag = ?
+		`), id, tag); err != nil {
+			return fmt.Errorf("RemoveTags: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("RemoveTags: %w", err)
+	}
+	return nil
+}
+
+// SetPinned pins or unpins the conversation.
+func (c *convoDB) SetPinned(id string, pinned bool) error {
+	if _, err := c.db.Exec(c.db.Rebind(`
+		INSERT INTO
+		  conversation_meta (conversation_id, pinned)
+		VALUES
+		  (?, ?)
+		ON CONFLICT (conversation_id) DO UPDATE
+		SET
+		  pinned = excluded.pinned
+	`), id, pinned); err != nil {
+		return fmt.Errorf("SetPinned: %w", err)
+	}
+	return nil
+}
+
+// SetNote sets the note of the conversation, an empty note removes it.
+func (c *convoDB) SetNote(id, note string) error {
+	var value *string
+	if note != "" {
+		value = &note
+	}
+	if _, err := c.db.Exec(c.db.Rebind(`
+		INSERT INTO
+		  conversation_meta (conversation_id, note)
+		VALUES
+		  (?, ?)
+		ON CONFLICT (conversation_id) DO UPDATE
+		SET
+		  note = excluded.note
+	`), id, value); err != nil {
+		return fmt.Errorf("SetNote: %w", err)
+	}
+	return nil
+}
+
+// Meta returns the conversation with its pin, note and tags.
+func (c *convoDB) Meta(id string) (*Conversation, error) {
+	var convo Conversation
+	if err := c.db.Get(&convo, c.db.Rebind(`
+		SELECT
+		  c.*,`+conversationMeta+`
+		FROM
+		  conversations c`+conversationMetaJoin+`
+		WHERE
+		  c.id = ?
+	`), id); err != nil {
+		return nil, fmt.Errorf("Meta: %w", err)
+	}
+	return &convo, nil
+}
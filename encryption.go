@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/cache"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/argon2"
+)
+
+// Parameters of the argon2id key derivation.
+const (
+	keyTime    = 3
+	keyMemory  = 64 * 1024
+	keyThreads = 4
+	keyLen     = 32
+	saltLen    = 16
+)
+
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage the encryption of saved conversations",
+	Long: `Manage the encryption of saved conversations.
+
+Set encryption.enabled in your configuration file to encrypt the messages
+saved from then on, and run oi encryption encrypt to encrypt the history.
+
+The key is cached in plaintext in the cache directory for
+encryption.cache-ttl, so the passphrase is only asked for once in a while.
+Set it to 0 to ask for the passphrase every time instead.`,
+}
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the saved messages that aren't yet",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		n, err := db.Encrypt()
+		if err != nil {
+			return modsError{err, "Could not encrypt the conversations."}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Encrypted %d messages.\n", n)
+			if !config.Encryption.Enabled {
+				fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(
+					"Set encryption.enabled in your configuration file to encrypt the conversations saved from now on.",
+				))
+			}
+		}
+		return nil
+	},
+}
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the saved messages and remove the key",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		n, err := db.Decrypt()
+		if err != nil {
+			return modsError{err, "Could not decrypt the conversations."}
+		}
+		if err := deleteCachedKeys(); err != nil {
+			return modsError{err, "Could not remove the cached encryption key."}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Decrypted %d messages.\n", n)
+		}
+		return nil
+	},
+}
+
+var rotateKeyCmd string
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Encrypt the saved messages again with a new passphrase",
+	Example: `  oi encryption rotate
+  oi encryption rotate --key-cmd "pass show oi-new"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if config.Encryption.KeyCmd != "" && rotateKeyCmd == "" {
+			return newUserErrorf(
+				"Give the command printing the new passphrase with %s, and set it as %s afterwards.",
+				stderrStyles().Flag.Render("--key-cmd"),
+				stderrStyles().InlineCode.Render("key-cmd"),
+			)
+		}
+		// the current key is needed before asking for the new one.
+		if _, err := db.cipher(false); err != nil {
+			return modsError{err, "Could not unlock the encrypted conversations."}
+		}
+
+		var passphrase string
+		var err error
+		if rotateKeyCmd != "" {
+			passphrase, err = commandOutput(cmd.Context(), rotateKeyCmd)
+		} else {
+			passphrase, err = askPassphrase("New encryption passphrase", true)
+		}
+		if err != nil {
+			return modsError{err, "Could not get the new encryption passphrase."}
+		}
+		salt := make([]byte, saltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return modsError{err, "Could not create the encryption key."}
+		}
+		key := deriveKey(passphrase, salt)
+
+		n, err := db.Rekey(salt, key)
+		if err != nil {
+			return modsError{err, "Could not encrypt the conversations with the new key."}
+		}
+		if err := deleteCachedKeys(); err != nil {
+			return modsError{err, "Could not remove the cached encryption key."}
+		}
+		cacheKey(salt, key)
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Encrypted %d messages with the new key.\n", n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rotateCmd.Flags().StringVar(&rotateKeyCmd, "key-cmd", "", "Command printing the new passphrase, instead of asking for it")
+	encryptionCmd.AddCommand(encryptCmd, decryptCmd, rotateCmd)
+	rootCmd.AddCommand(encryptionCmd)
+}
+
+// unlockEncryption gets the encryption key before a conversation is read or
+// saved, as it can't be asked for while the response is shown.
+func unlockEncryption(cfg *Config) error {
+	show := cfg.Show != "" || cfg.ShowLast
+	save := cfg.Encryption.Enabled && !show && !skipPipelineSave(cfg)
+	read := false
+	if !cfg.NoCache && (cfg.Continue != "" || cfg.ContinueLast || show) {
+		count, err := db.CountEncrypted()
+		if err != nil {
+			return modsError{err, "Could not read the conversations."}
+		}
+		read = count > 0
+	}
+	if !save && !read {
+		return nil
+	}
+	if _, err := db.cipher(save); err != nil {
+		return modsError{err, "Could not unlock the encrypted conversations."}
+	}
+	return nil
+}
+
+// getEncryptionKey returns the key derived with the given salt from the
+// passphrase printed by key-cmd or asked for. Keys are cached for cache-ttl
+// so the passphrase is only needed once per session.
+func getEncryptionKey(salt []byte, isNew bool, check func(key []byte) bool) ([]byte, error) {
+	if !isNew {
+		if key := cachedKey(salt); key != nil && check(key) {
+			return key, nil
+		}
+	}
+
+	var passphrase string
+	var err error
+	if config.Encryption.KeyCmd != "" {
+		passphrase, err = commandOutput(context.Background(), config.Encryption.KeyCmd)
+		if err != nil {
+			return nil, fmt.Errorf("key-cmd: %w", err)
+		}
+	} else {
+		title := "Encryption passphrase"
+		if isNew {
+			title = "New encryption passphrase"
+		}
+		if passphrase, err = askPassphrase(title, isNew); err != nil {
+			return nil, err
+		}
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("empty encryption passphrase")
+	}
+
+	key := deriveKey(passphrase, salt)
+	if !isNew && !check(key) {
+		return nil, errWrongKey
+	}
+	cacheKey(salt, key)
+	return key, nil
+}
+
+func deriveKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, keyTime, keyMemory, keyThreads, keyLen)
+}
+
+// askPassphrase asks for the passphrase in the terminal, twice if confirm is
+// true.
+func askPassphrase(title string, confirm bool) (string, error) {
+	if !isInputTTY() {
+		return "", newUserErrorf(
+			"Could not ask for the encryption passphrase, set %s or run oi in a terminal first, the key is then cached for %s.",
+			stderrStyles().InlineCode.Render("key-cmd"),
+			config.Encryption.CacheTTL,
+		)
+	}
+	var passphrase, again string
+	fields := []huh.Field{
+		huh.NewInput().
+			Title(title).
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().
+			Title("Repeat the passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&again))
+	}
+	if err := huh.NewForm(huh.NewGroup(fields...)).WithOutput(os.Stderr).Run(); err != nil {
+		return "", fmt.Errorf("could not ask for the passphrase: %w", err)
+	}
+	if confirm && passphrase != again {
+		return "", newUserErrorf("The passphrases don't match.")
+	}
+	return passphrase, nil
+}
+
+// encryptionKeyCacheID identifies the cached key of a salt.
+func encryptionKeyCacheID(salt []byte) string {
+	sum := sha256.Sum256(salt)
+	return "encryption-key-" + hex.EncodeToString(sum[:])
+}
+
+func cachedKey(salt []byte) []byte {
+	if config.Encryption.CacheTTL <= 0 {
+		return nil
+	}
+	c, err := cache.NewExpiring[string](config.CachePath)
+	if err != nil {
+		return nil
+	}
+	var cached bytes.Buffer
+	if err := c.Read(encryptionKeyCacheID(salt), func(r io.Reader) error {
+		_, err := io.Copy(&cached, r)
+		return err //nolint:wrapcheck
+	}); err != nil {
+		return nil
+	}
+	key, err := hex.DecodeString(cached.String())
+	if err != nil || len(key) != keyLen {
+		return nil
+	}
+	return key
+}
+
+func cacheKey(salt, key []byte) {
+	if config.Encryption.CacheTTL <= 0 {
+		return
+	}
+	c, err := cache.NewExpiring[string](config.CachePath)
+	if err != nil {
+		return
+	}
+	expiresAt := time.Now().Add(config.Encryption.CacheTTL).Unix()
+	_ = c.Write(encryptionKeyCacheID(salt), expiresAt, func(w io.Writer) error {
+		_, err := io.WriteString(w, hex.EncodeToString(key))
+		return err //nolint:wrapcheck
+	})
+}
+
+// deleteCachedKeys removes the cached keys, which are of no use once the
+// key they belong to is replaced or removed.
+func deleteCachedKeys() error {
+	c, err := cache.NewExpiring[string](config.CachePath)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return c.Delete("encryption-key-*") //nolint:wrapcheck
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportAll    bool
+	exportSearch string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [id|title...]",
+	Short: "Export saved conversations to Markdown, JSON, JSONL or HTML",
+	Example: `  oi export 3f2a --format html > chat.html
+  oi export --all --format json --output ~/oi-backup
+  oi export --search nginx --format jsonl > nginx.jsonl`,
+	RunE: func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(export.Formats, exportFormat) {
+			return newUserErrorf("Invalid format %q, valid formats are: %s.", exportFormat, strings.Join(export.Formats, ", "))
+		}
+		conversations, err := exportConversations(args)
+		if err != nil {
+			return err
+		}
+		if len(conversations) == 0 {
+			return newUserErrorf("No conversations to export.")
+		}
+
+		if exportOutput == "" {
+			if len(conversations) > 1 && exportFormat != export.FormatJSONL {
+				return newUserErrorf(
+					"Exporting several conversations needs %s, or %s to write them all to stdout.",
+					stderrStyles().Flag.Render("--output <dir>"),
+					stderrStyles().Flag.Render("--format jsonl"),
+				)
+			}
+			w := bufio.NewWriter(os.Stdout)
+			for _, convo := range conversations {
+				if err := export.Write(w, exportFormat, convo); err != nil {
+					return modsError{err, "Could not export the conversation."}
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return modsError{err, "Could not export the conversation."}
+			}
+			return nil
+		}
+
+		dir := expandHome(exportOutput)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return modsError{err, "Could not create the output directory."}
+		}
+		for _, convo := range conversations {
+			if err := exportToFile(filepath.Join(dir, exportFileName(convo)), convo); err != nil {
+				return modsError{err, "Could not export the conversation."}
+			}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Exported %d conversations to %s\n", len(conversations), dir)
+		}
+		return nil
+	},
+	ValidArgsFunction: func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		results, _ := db.Completions(toComplete)
+		return results, cobra.ShellCompDirectiveDefault
+	},
+}
+
+func init() {
+	flags := exportCmd.Flags()
+	flags.StringVarP(&exportFormat, "format", "f", export.FormatMarkdown, "Export format: "+strings.Join(export.Formats, ", "))
+	flags.StringVarP(&exportOutput, "output", "o", "", "Write one file per conversation to this directory instead of stdout")
+	flags.BoolVar(&exportAll, "all", false, "Export all the saved conversations")
+	flags.StringVar(&exportSearch, "search", "", "Export the conversations matching this search")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return export.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(exportCmd)
+}
+
+// exportConversations loads the conversations given as arguments, or the
+// ones selected with --all or --search.
+func exportConversations(args []string) ([]export.Conversation, error) {
+	var found []Conversation
+	switch {
+	case len(args) > 0:
+		for _, arg := range args {
+			convo, err := db.Find(arg)
+			if err != nil {
+				return nil, modsError{err, "Could not find the conversation."}
+			}
+			found = append(found, *convo)
+		}
+	case exportAll:
+		all, err := db.List()
+		if err != nil {
+			return nil, modsError{err, "Could not list the conversations."}
+		}
+		found = all
+	case exportSearch != "":
+		hits, err := db.Search(exportSearch, searchLimit)
+		if err != nil {
+			return nil, modsError{err, "Could not search the conversations."}
+		}
+		found = hits
+	default:
+		return nil, newUserErrorf(
+			"Missing conversation to export, give an ID or title, %s or %s.",
+			stderrStyles().Flag.Render("--all"),
+			stderrStyles().Flag.Render("--search"),
+		)
+	}
+
+	conversations := make([]export.Conversation, 0, len(found))
+	for _, c := range found {
+		messages, err := db.Messages(c.ID)
+		if errors.Is(err, errNoMessages) && len(args) == 0 {
+			// only report it when asked for the conversation.
+			continue
+		}
+		if err != nil {
+			return nil, modsError{err, "Could not read the conversation."}
+		}
+		convo := export.Conversation{
+			ID:        c.ID,
+			Title:     c.Title,
+			UpdatedAt: c.UpdatedAt,
+			Messages:  messages,
+		}
+		if c.API != nil {
+			convo.API = *c.API
+		}
+		if c.Model != nil {
+			convo.Model = *c.Model
+		}
+		conversations = append(conversations, convo)
+	}
+	return conversations, nil
+}
+
+func exportToFile(path string, convo export.Conversation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", path, err)
+	}
+	w := bufio.NewWriter(f)
+	if err := export.Write(w, exportFormat, convo); err != nil {
+		_ = f.Close()
+		return err //nolint:wrapcheck
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return f.Close() //nolint:wrapcheck
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// exportFileName names the export of a conversation after its ID and title.
+func exportFileName(convo export.Conversation) string {
+	const maxSlug = 48
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(convo.Title), "-"), "-")
+	if len(slug) > maxSlug {
+		slug = strings.TrimRight(slug[:maxSlug], "-")
+	}
+	name := convo.ID[:sha1short]
+	if slug != "" {
+		name += "-" + slug
+	}
+	return name + "." + exportFormat
+}
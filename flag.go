@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -87,3 +88,36 @@ func (d *durationFlag) String() string {
 func (*durationFlag) Type() string {
 	return "duration"
 }
+
+func newTimeFlag(p *time.Time) *timeFlag {
+	return (*timeFlag)(p)
+}
+
+// timeFlag is a date, a date and time, or a duration ago, e.g. 7d.
+type timeFlag time.Time
+
+func (t *timeFlag) Set(s string) error {
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if v, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			*t = timeFlag(v)
+			return nil
+		}
+	}
+	d, err := duration.Parse(s)
+	if err != nil {
+		return fmt.Errorf("%q is neither a date, like 2006-01-02, nor a duration, like 7d", s)
+	}
+	*t = timeFlag(time.Now().Add(-d))
+	return nil
+}
+
+func (t *timeFlag) String() string {
+	if time.Time(*t).IsZero() {
+		return ""
+	}
+	return time.Time(*t).Format(time.DateTime)
+}
+
+func (*timeFlag) Type() string {
+	return "time"
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+)
+
+// parseFork splits a --fork argument into the conversation and the turn to
+// fork at, which is 0 to fork the whole conversation.
+func parseFork(in string) (string, int, error) {
+	i := strings.LastIndex(in, "@")
+	if i < 0 {
+		return in, 0, nil
+	}
+	turn, err := strconv.Atoi(in[i+1:])
+	if err != nil {
+		// Titles may contain an @.
+		return in, 0, nil //nolint:nilerr
+	}
+	if turn < 1 {
+		return "", 0, newUserErrorf("Invalid turn %d in %s, turns start at 1.", turn, stderrStyles().Flag.Render("--fork"))
+	}
+	return in[:i], turn, nil
+}
+
+// countTurns returns the number of user messages.
+func countTurns(messages []proto.Message) int {
+	var turns int
+	for _, msg := range messages {
+		if msg.Role == proto.RoleUser {
+			turns++
+		}
+	}
+	return turns
+}
+
+// messagesUpToTurn returns the messages before the user message following
+// the given turn, so the last turn keeps its responses and tool calls.
+func messagesUpToTurn(messages []proto.Message, turn int) []proto.Message {
+	var turns int
+	for i, msg := range messages {
+		if msg.Role != proto.RoleUser {
+			continue
+		}
+		turns++
+		if turns > turn {
+			return messages[:i]
+		}
+	}
+	return messages
+}
+
+// forkConversation saves a new conversation with the history of the one
+// given to --fork, up to its turn, and returns its ID.
+func forkConversation(in, title string) (string, error) {
+	ref, turn, err := parseFork(in)
+	if err != nil {
+		return "", err
+	}
+	parent, err := db.Find(ref)
+	if err != nil {
+		return "", modsError{err, "Could not find the conversation to fork."}
+	}
+	messages, err := db.Messages(parent.ID)
+	if err != nil {
+		return "", modsError{err, "Could not read the conversation to fork."}
+	}
+
+	turns := countTurns(messages)
+	if turn == 0 {
+		turn = turns
+	}
+	if turn > turns {
+		return "", newUserErrorf("Conversation %s only has %d turns.", parent.ID[:sha1short], turns)
+	}
+	titleSource := titleFromUser
+	if title == "" {
+		if title, err = forkTitle(parent.Title); err != nil {
+			return "", modsError{err, "Could not fork the conversation."}
+		}
+		titleSource = titleFromPrompt
+		if parent.TitleSource != nil {
+			titleSource = *parent.TitleSource
+		}
+	} else if _, err := db.Find(title); !errors.Is(err, errNoMatches) {
+		return "", newUserErrorf("A conversation titled %q already exists, give the fork another %s.", title, stderrStyles().Flag.Render("--title"))
+	}
+
+	id := newConversationID()
+	if err := db.Fork(*parent, id, title, turn, messagesUpToTurn(messages, turn)); err != nil {
+		return "", modsError{err, "Could not fork the conversation."}
+	}
+	if err := db.SetTitleSource(id, titleSource); err != nil {
+		return "", modsError{err, "Could not fork the conversation."}
+	}
+	if !config.Quiet {
+		fmt.Fprintln(
+			os.Stderr,
+			"Conversation forked:",
+			stderrStyles().InlineCode.Render(id[:sha1short]),
+			stderrStyles().Comment.Render(fmt.Sprintf("%s @%d", title, turn)),
+		)
+	}
+	return id, nil
+}
+
+// forkTitle returns the title of a fork of the conversation with the given
+// title, which is distinct so both can be found by their title.
+func forkTitle(parent string) (string, error) {
+	for n := 1; ; n++ {
+		title := parent + " (fork)"
+		if n > 1 {
+			title = fmt.Sprintf("%s (fork %d)", parent, n)
+		}
+		_, err := db.Find(title)
+		if errors.Is(err, errNoMatches) {
+			return title, nil
+		}
+		if err != nil && !errors.Is(err, errManyMatches) {
+			return "", err
+		}
+	}
+}
+
+// forkTree orders the conversations so forks follow their parent, and sets
+// the prefix drawing the tree. Conversations whose parent isn't listed are
+// shown at the top level.
+func forkTree(conversations []Conversation) []Conversation {
+	listed := map[string]bool{}
+	for _, c := range conversations {
+		listed[c.ID] = true
+	}
+	children := map[string][]Conversation{}
+	var roots []Conversation
+	for _, c := range conversations {
+		if c.ParentID != nil && listed[*c.ParentID] && *c.ParentID != c.ID {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+			continue
+		}
+		roots = append(roots, c)
+	}
+
+	result := make([]Conversation, 0, len(conversations))
+	var walk func(c Conversation, prefix, indent string)
+	walk = func(c Conversation, prefix, indent string) {
+		c.treePrefix = prefix
+		result = append(result, c)
+		forks := children[c.ID]
+		delete(children, c.ID)
+		for i, child := range forks {
+			if i == len(forks)-1 {
+				walk(child, indent+"└─ ", indent+"   ")
+			} else {
+				walk(child, indent+"├─ ", indent+"│  ")
+			}
+		}
+	}
+	for _, c := range roots {
+		walk(c, "", "")
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/spf13/cobra"
+)
+
+// historyTarget finds the conversation given to --continue, or the last
+// one, and its messages.
+func historyTarget() (*Conversation, []proto.Message, error) {
+	var convo *Conversation
+	var err error
+	if config.Continue != "" && config.Continue != "__EMPTY__" {
+		convo, err = db.Find(config.Continue)
+	} else {
+		convo, err = db.FindHEAD()
+	}
+	if err != nil {
+		return nil, nil, modsError{err, "Could not find the conversation."}
+	}
+	messages, err := db.Messages(convo.ID)
+	if err != nil {
+		return nil, nil, modsError{err, "Could not read the conversation."}
+	}
+	return convo, messages, nil
+}
+
+// lastTurnIndex returns the index of the last user message, or the length
+// of messages if there is none.
+func lastTurnIndex(messages []proto.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == proto.RoleUser {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+// undoLastTurn removes the last user message of the conversation and
+// everything after it, i.e. the responses and tool calls.
+func undoLastTurn() error {
+	convo, messages, err := historyTarget()
+	if err != nil {
+		return err
+	}
+	idx := lastTurnIndex(messages)
+	if idx == len(messages) {
+		return newUserErrorf("Conversation %s has nothing to undo.", convo.ID[:sha1short])
+	}
+
+	var api, model string
+	if convo.API != nil {
+		api = *convo.API
+	}
+	if convo.Model != nil {
+		model = *convo.Model
+	}
+	if err := db.Save(convo.ID, convo.Title, api, model, messages[:idx]); err != nil {
+		return modsError{err, "Could not save the conversation."}
+	}
+	if !config.Quiet {
+		fmt.Fprintln(
+			os.Stderr,
+			"Removed the last turn of",
+			stderrStyles().InlineCode.Render(convo.ID[:sha1short]),
+			stderrStyles().Comment.Render(firstLine(messages[idx].Content)),
+		)
+	}
+	return nil
+}
+
+// prepareRerun sets up the configuration to generate the last turn of the
+// conversation again, with its prompt edited first for --edit-last. The
+// replaced turn is kept as an alternative once the new one is saved.
+func prepareRerun(cmd *cobra.Command) error {
+	convo, messages, err := historyTarget()
+	if err != nil {
+		return err
+	}
+	idx := lastTurnIndex(messages)
+	if idx == len(messages) {
+		return newUserErrorf("Conversation %s has no prompt to run again.", convo.ID[:sha1short])
+	}
+
+	prompt := messages[idx].Content
+	if config.EditLast {
+		if prompt, err = editText(prompt); err != nil {
+			return modsError{err, "Could not edit the prompt."}
+		}
+		if strings.TrimSpace(prompt) == "" {
+			return newUserErrorf("The edited prompt is empty.")
+		}
+	}
+
+	config.Continue = convo.ID
+	config.ContinueLast = false
+	config.Title = ""
+	config.Prefix = prompt
+	config.cacheDropLastTurn = true
+	config.cacheKeepModel = cmd.Flags().Changed("model") || cmd.Flags().Changed("api")
+	return nil
+}
+
+// editText opens $VISUAL or $EDITOR on the text and returns it edited.
+func editText(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return "", errors.New("neither $VISUAL nor $EDITOR are set")
+	}
+
+	f, err := os.CreateTemp("", "oi-*.md")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("could not write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("could not write temporary file: %w", err)
+	}
+
+	// The editor may have arguments, e.g. code --wait.
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name()) //nolint:gosec
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", editor, err)
+	}
+
+	bts, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("could not read temporary file: %w", err)
+	}
+	return strings.TrimSpace(string(bts)), nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/cache"
+	"github.com/GuntuAshok/oi/internal/export"
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|dir>...",
+	Short: "Import conversations from exports of oi, OpenAI-style tools, Open WebUI or a mods data directory",
+	Example: `  oi import chat.json
+  oi import ~/Downloads/open-webui-export.json
+  oi import ~/.local/share/mods`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		imp, err := newImporter()
+		if err != nil {
+			return err
+		}
+		for _, arg := range args {
+			if err := imp.importPath(expandHome(arg)); err != nil {
+				return err
+			}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Imported %d conversations", imp.imported)
+			if imp.duplicates > 0 {
+				fmt.Fprintf(os.Stderr, ", skipped %d already saved", imp.duplicates)
+			}
+			if imp.unreadable > 0 {
+				fmt.Fprintf(os.Stderr, ", skipped %d whose messages couldn't be read", imp.unreadable)
+			}
+			fmt.Fprintln(os.Stderr, ".")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+// importer saves imported conversations, skipping the ones whose messages
+// are already saved.
+type importer struct {
+	hashes     map[string]struct{}
+	imported   int
+	duplicates int
+	// unreadable counts the conversations of mods data directories whose
+	// messages couldn't be read.
+	unreadable int
+}
+
+func newImporter() (*importer, error) {
+	conversations, err := db.List()
+	if err != nil {
+		return nil, modsError{err, "Could not list the conversations."}
+	}
+	imp := &importer{hashes: map[string]struct{}{}}
+	for _, c := range conversations {
+		messages, err := db.Messages(c.ID)
+		if errors.Is(err, errNoMessages) {
+			continue
+		}
+		if err != nil {
+			return nil, modsError{err, "Could not read the conversations."}
+		}
+		imp.hashes[export.Conversation{Messages: messages}.Hash()] = struct{}{}
+	}
+	return imp, nil
+}
+
+// importPath imports a file, a mods data directory, or the exports in a
+// directory.
+func (imp *importer) importPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return modsError{err, "Could not import the conversations."}
+	}
+	if !info.IsDir() {
+		return imp.importFile(path)
+	}
+
+	for _, dbPath := range []string{
+		filepath.Join(path, string(cache.ConversationCache), "mods.db"),
+		filepath.Join(path, "mods.db"),
+	} {
+		if _, err := os.Stat(dbPath); err == nil {
+			conversations, skipped, err := readModsDB(dbPath)
+			if err != nil {
+				return modsError{err, "Could not import the mods data directory."}
+			}
+			imp.unreadable += skipped
+			return imp.save(conversations)
+		}
+	}
+
+	return filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error { //nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (filepath.Ext(file) != ".json" && filepath.Ext(file) != ".jsonl") {
+			return nil
+		}
+		err = imp.importFile(file)
+		var merr modsError
+		if errors.As(err, &merr) && errors.Is(merr.err, export.ErrUnknownFormat) {
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", file, merr.err)
+			}
+			return nil
+		}
+		return err
+	})
+}
+
+func (imp *importer) importFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return modsError{err, "Could not read the file to import."}
+	}
+	conversations, err := export.Parse(data)
+	if err != nil {
+		return modsError{fmt.Errorf("%s: %w", path, err), "Could not import the file."}
+	}
+	return imp.save(conversations)
+}
+
+func (imp *importer) save(conversations []export.Conversation) error {
+	for _, convo := range conversations {
+		if len(convo.Messages) == 0 {
+			continue
+		}
+		hash := convo.Hash()
+		if _, ok := imp.hashes[hash]; ok {
+			imp.duplicates++
+			continue
+		}
+
+		id := convo.ID
+		if !sha1reg.MatchString(id) || len(id) != len(newConversationID()) {
+			id = newConversationID()
+		} else if _, err := db.Find(id); err == nil {
+			id = newConversationID()
+		}
+		title := strings.TrimSpace(convo.Title)
+		if title == "" {
+			title = firstLine(firstPrompt(convo.Messages))
+		}
+		if title == "" {
+			title = "Imported conversation"
+		}
+
+		if err := db.Save(id, title, convo.API, convo.Model, convo.Messages); err != nil {
+			return modsError{err, "Could not save the imported conversation."}
+		}
+		if !convo.UpdatedAt.IsZero() {
+			if err := db.SetUpdatedAt(id, convo.UpdatedAt); err != nil {
+				return modsError{err, "Could not save the imported conversation."}
+			}
+		}
+		imp.hashes[hash] = struct{}{}
+		imp.imported++
+	}
+	return nil
+}
+
+// firstPrompt returns the first user message.
+func firstPrompt(messages []proto.Message) string {
+	for _, msg := range messages {
+		if msg.Role == proto.RoleUser {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+// readModsDB reads the conversations of a mods data directory, or of an
+// older oi one, without changing it. Messages are read from the gob files
+// next to the database, or from the database itself if it has them. It also
+// returns the number of conversations whose messages couldn't be read.
+func readModsDB(path string) ([]export.Conversation, int, error) {
+	src, err := sqlx.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not open %s: %w", path, handleSqliteErr(err))
+	}
+	defer src.Close() //nolint:errcheck
+
+	columns := "id, title, updated_at"
+	for _, col := range []string{"api", "model"} {
+		if hasColumn(src, col) {
+			columns += ", " + col
+		}
+	}
+	var rows []Conversation
+	if err := src.Select(&rows, `SELECT `+columns+` FROM conversations ORDER BY updated_at`); err != nil {
+		return nil, 0, fmt.Errorf("could not read %s: %w", path, handleSqliteErr(err))
+	}
+
+	stored := &convoDB{db: src}
+
+	conversations := make([]export.Conversation, 0, len(rows))
+	var skipped int
+	for _, row := range rows {
+		var messages []proto.Message
+		if err := cache.ReadConversation(filepath.Dir(path), row.ID, &messages); err != nil {
+			if messages, err = stored.Messages(row.ID); err != nil {
+				skipped++
+				continue
+			}
+		}
+		convo := export.Conversation{
+			ID:        row.ID,
+			Title:     row.Title,
+			UpdatedAt: row.UpdatedAt,
+			Messages:  messages,
+		}
+		if row.API != nil {
+			convo.API = *row.API
+		}
+		if row.Model != nil {
+			convo.Model = *row.Model
+		}
+		conversations = append(conversations, convo)
+	}
+	return conversations, skipped, nil
+}
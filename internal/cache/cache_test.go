@@ -3,6 +3,8 @@ package cache
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -47,6 +49,25 @@ func TestCache(t *testing.T) {
 		require.ErrorIs(t, cache.Read("fake", nil), os.ErrNotExist)
 	})
 
+	t.Run("read file", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := NewConversations(dir)
+		require.NoError(t, err)
+		messages := []proto.Message{{Role: proto.RoleUser, Content: "hi"}}
+		require.NoError(t, cache.Write("fake", &messages))
+
+		result := []proto.Message{}
+		require.NoError(t, ReadConversation(filepath.Join(dir, string(ConversationCache)), "fake", &result))
+		require.Equal(t, messages, result)
+	})
+
+	t.Run("read file without creating the directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "missing")
+		err := ReadConversation(dir, "fake", &[]proto.Message{})
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.NoDirExists(t, dir)
+	})
+
 	t.Run("invalid id", func(t *testing.T) {
 		t.Run("write", func(t *testing.T) {
 			cache, err := NewConversations(t.TempDir())
@@ -94,6 +115,23 @@ func TestExpiringCache(t *testing.T) {
 		require.Equal(t, data, result)
 	})
 
+	t.Run("private files", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not supported on windows")
+		}
+		cache, err := NewExpiring[string](t.TempDir())
+		require.NoError(t, err)
+
+		expiresAt := time.Now().Add(time.Hour).Unix()
+		require.NoError(t, cache.Write("secret", expiresAt, func(w io.Writer) error {
+			_, err := w.Write([]byte("key"))
+			return err
+		}))
+		info, err := os.Stat(filepath.Join(cache.cache.dir(), cache.getCacheFilename("secret", expiresAt)))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
 	t.Run("expired token", func(t *testing.T) {
 		cache, err := NewExpiring[string](t.TempDir())
 		require.NoError(t, err)
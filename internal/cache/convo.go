@@ -6,11 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/GuntuAshok/oi/internal/proto"
 )
 
 // Conversations is the conversation cache.
+//
+// Messages are now stored in the database, this is only used to read the
+// conversations saved by older versions.
 type Conversations struct {
 	cache *Cache[[]proto.Message]
 }
@@ -26,6 +31,25 @@ func NewConversations(dir string) (*Conversations, error) {
 	}, nil
 }
 
+// ReadConversation reads the conversation with the given ID from the gob
+// files in dir, without creating it, so the data directories of older
+// versions are left untouched.
+func ReadConversation(dir, id string, messages *[]proto.Message) error {
+	if id == "" {
+		return fmt.Errorf("read: %w", errInvalidID)
+	}
+	file, err := os.Open(filepath.Join(dir, id+cacheExt))
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	if err := decode(file, messages); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	return nil
+}
+
 func (c *Conversations) Read(id string, messages *[]proto.Message) error {
 	return c.cache.Read(id, func(r io.Reader) error {
 		return decode(r, messages)
@@ -81,8 +81,9 @@ func (c *ExpiringCache[T]) Write(id string, expiresAt int64, writeFn func(io.Wri
 		}
 	}
 
+	// cached items may be secrets, so only the user can read them.
 	filename := c.getCacheFilename(id, expiresAt)
-	file, err := os.Create(filepath.Join(c.cache.dir(), filename))
+	file, err := os.OpenFile(filepath.Join(c.cache.dir(), filename), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return fmt.Errorf("failed to create expiring cache file: %w", err)
 	}
@@ -0,0 +1,211 @@
+// Package export writes conversations in formats other tools understand.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+)
+
+// Export formats, which are also the extensions of the exported files.
+const (
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatHTML     = "html"
+)
+
+// Formats are all the supported formats.
+var Formats = []string{FormatMarkdown, FormatJSON, FormatJSONL, FormatHTML}
+
+// Version of the JSON export format.
+const Version = 1
+
+// Conversation is a saved conversation and its metadata.
+type Conversation struct {
+	ID        string
+	Title     string
+	API       string
+	Model     string
+	UpdatedAt time.Time
+	Messages  []proto.Message
+}
+
+// Write writes the conversation in the given format.
+func Write(w io.Writer, format string, convo Conversation) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, convo)
+	case FormatJSON:
+		return writeJSON(w, convo)
+	case FormatJSONL:
+		return writeJSONL(w, convo)
+	case FormatHTML:
+		return writeHTML(w, convo)
+	default:
+		return fmt.Errorf("unknown format %q, valid formats are: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func writeMarkdown(w io.Writer, convo Conversation) error {
+	info := strings.Join(append([]string{"`" + convo.ID + "`"}, metadata(convo)...), " · ")
+	if _, err := fmt.Fprintf(w, "# %s\n\n%s\n\n", convo.Title, info); err != nil {
+		return fmt.Errorf("could not write markdown: %w", err)
+	}
+	if _, err := io.WriteString(w, proto.Conversation(convo.Messages).Render(proto.VerbosityFull)); err != nil {
+		return fmt.Errorf("could not write markdown: %w", err)
+	}
+	return nil
+}
+
+// metadata describes the model and date of the conversation.
+func metadata(convo Conversation) []string {
+	var parts []string
+	if convo.Model != "" {
+		model := convo.Model
+		if convo.API != "" {
+			model += " (" + convo.API + ")"
+		}
+		parts = append(parts, model)
+	}
+	if !convo.UpdatedAt.IsZero() {
+		parts = append(parts, convo.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+	return parts
+}
+
+// Document is the JSON export of a conversation.
+type Document struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	API       string    `json:"api,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Messages  []Message `json:"messages"`
+}
+
+// Message is a message in the JSON export.
+type Message struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Images    [][]byte       `json:"images,omitempty"`
+	ToolCalls []ToolCall     `json:"tool_calls,omitempty"`
+	Metrics   *proto.Metrics `json:"metrics,omitempty"`
+}
+
+// ToolCall is a tool call in the JSON export. Arguments that aren't valid
+// JSON are exported as a string.
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Duration  time.Duration   `json:"duration_ns,omitempty"`
+}
+
+// NewDocument creates the JSON export of the conversation.
+func NewDocument(convo Conversation) Document {
+	doc := Document{
+		Version:   Version,
+		ID:        convo.ID,
+		Title:     convo.Title,
+		API:       convo.API,
+		Model:     convo.Model,
+		UpdatedAt: convo.UpdatedAt,
+		Messages:  make([]Message, 0, len(convo.Messages)),
+	}
+	for _, msg := range convo.Messages {
+		m := Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+			Images:  msg.Images,
+			Metrics: msg.Metrics,
+		}
+		for _, call := range msg.ToolCalls {
+			args := json.RawMessage(call.Function.Arguments)
+			if len(args) > 0 && !json.Valid(args) {
+				args, _ = json.Marshal(string(call.Function.Arguments))
+			}
+			m.ToolCalls = append(m.ToolCalls, ToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: args,
+				IsError:   call.IsError,
+				Duration:  call.Duration,
+			})
+		}
+		doc.Messages = append(doc.Messages, m)
+	}
+	return doc
+}
+
+func writeJSON(w io.Writer, convo Conversation) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(NewDocument(convo)); err != nil {
+		return fmt.Errorf("could not write json: %w", err)
+	}
+	return nil
+}
+
+// chatMessage is a message in the chat fine-tuning format.
+type chatMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function chatToolFunction `json:"function"`
+}
+
+type chatToolFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// writeJSONL writes the conversation as a single line in the chat
+// fine-tuning format, so the exports of several conversations can be
+// concatenated into a training set.
+func writeJSONL(w io.Writer, convo Conversation) error {
+	messages := make([]chatMessage, 0, len(convo.Messages))
+	for _, msg := range convo.Messages {
+		m := chatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+		switch msg.Role {
+		case proto.RoleAssistant:
+			for _, call := range msg.ToolCalls {
+				m.ToolCalls = append(m.ToolCalls, chatToolCall{
+					ID:   call.ID,
+					Type: "function",
+					Function: chatToolFunction{
+						Name:      call.Function.Name,
+						Arguments: string(call.Function.Arguments),
+					},
+				})
+			}
+		case proto.RoleTool:
+			if len(msg.ToolCalls) > 0 {
+				m.ToolCallID = msg.ToolCalls[0].ID
+			}
+		}
+		messages = append(messages, m)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(map[string]any{"messages": messages}); err != nil {
+		return fmt.Errorf("could not write jsonl: %w", err)
+	}
+	return nil
+}
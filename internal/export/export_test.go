@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/stretchr/testify/require"
+)
+
+func testConversation() Conversation {
+	return Conversation{
+		ID:        "df31ae23ab8b75b5643c2f846c570997edc71333",
+		Title:     "first 4 natural numbers",
+		API:       "ollama",
+		Model:     "llama3.2",
+		UpdatedAt: time.Date(2025, time.March, 14, 15, 9, 26, 0, time.UTC),
+		Messages: []proto.Message{
+			{
+				Role:    proto.RoleSystem,
+				Content: "you are a medieval king",
+			},
+			{
+				Role:    proto.RoleUser,
+				Content: "first 4 natural numbers, <b>please</b>",
+			},
+			{
+				Role: proto.RoleAssistant,
+				ToolCalls: []proto.ToolCall{
+					{
+						ID: "0",
+						Function: proto.Function{
+							Name:      "builtin_run_command",
+							Arguments: []byte(`{"command":"seq 4"}`),
+						},
+					},
+				},
+			},
+			{
+				Role:    proto.RoleTool,
+				Content: "1\n2\n3\n4",
+				ToolCalls: []proto.ToolCall{
+					{
+						ID: "0",
+						Function: proto.Function{
+							Name:      "builtin_run_command",
+							Arguments: []byte(`{"command":"seq 4"}`),
+						},
+						Duration: 12 * time.Millisecond,
+					},
+				},
+			},
+			{
+				Role:    proto.RoleAssistant,
+				Content: "Here they are:\n\n```\n1, 2, 3, 4\n```",
+				Metrics: &proto.Metrics{
+					PromptTokens:     42,
+					CompletionTokens: 12,
+					Duration:         time.Second,
+				},
+			},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, format, testConversation()))
+			golden.RequireEqual(t, buf.Bytes())
+		})
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	require.Error(t, Write(&bytes.Buffer{}, "pdf", testConversation()))
+}
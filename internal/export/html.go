@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders message contents. Raw HTML in the contents is not
+// rendered, so exported pages are safe to open.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+:root { color-scheme: light dark; --accent: #ff5f87; --muted: #757575; --border: #8884; }
+body { font-family: system-ui, sans-serif; line-height: 1.5; max-width: 50rem; margin: 2rem auto; padding: 0 1rem; }
+header p { color: var(--muted); font-size: .9rem; }
+article { border: 1px solid var(--border); border-radius: .5rem; padding: .5rem 1rem; margin: 1rem 0; }
+article h2 { font-size: .8rem; text-transform: uppercase; letter-spacing: .05em; color: var(--muted); margin: .25rem 0; }
+article.user { border-left: 4px solid var(--accent); }
+article.system { opacity: .7; }
+article.error h2 { color: var(--accent); }
+pre { overflow-x: auto; padding: .5rem; border-radius: .25rem; background: #8881; }
+code { font-family: ui-monospace, monospace; font-size: .9em; }
+img { max-width: 100%; }
+details summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{ .Title }}</h1>
+<p>{{ .Metadata }}</p>
+</header>
+{{- range .Messages }}
+<article class="{{ .Class }}">
+<h2>{{ .Role }}</h2>
+{{- range .ToolCalls }}
+<details>
+<summary>Tool <code>{{ .Name }}</code>{{ if .Duration }} in {{ .Duration }}{{ end }}</summary>
+{{- if .Arguments }}
+<pre><code>{{ .Arguments }}</code></pre>
+{{- end }}
+</details>
+{{- end }}
+{{- if .Tool }}
+<pre><code>{{ .Content }}</code></pre>
+{{- else }}
+{{ .HTML }}
+{{- end }}
+{{- range .Images }}
+<img src="{{ . }}" alt="">
+{{- end }}
+</article>
+{{- end }}
+</body>
+</html>
+`))
+
+type htmlPage struct {
+	Title    string
+	Metadata string
+	Messages []htmlMessage
+}
+
+type htmlMessage struct {
+	Role      string
+	Class     string
+	Tool      bool
+	Content   string
+	HTML      template.HTML
+	ToolCalls []htmlToolCall
+	Images    []template.URL
+}
+
+type htmlToolCall struct {
+	Name      string
+	Arguments string
+	Duration  string
+}
+
+func writeHTML(w io.Writer, convo Conversation) error {
+	data := htmlPage{
+		Title:    convo.Title,
+		Metadata: strings.Join(append([]string{convo.ID}, metadata(convo)...), " · "),
+	}
+	for _, msg := range convo.Messages {
+		if msg.Content == "" && len(msg.ToolCalls) == 0 && len(msg.Images) == 0 {
+			continue
+		}
+		m := htmlMessage{
+			Role:    msg.Role,
+			Class:   msg.Role,
+			Tool:    msg.Role == proto.RoleTool,
+			Content: msg.Content,
+		}
+		if !m.Tool {
+			var buf bytes.Buffer
+			if err := markdown.Convert([]byte(msg.Content), &buf); err != nil {
+				return fmt.Errorf("could not render message: %w", err)
+			}
+			m.HTML = template.HTML(buf.String()) //nolint:gosec
+		}
+		for _, call := range msg.ToolCalls {
+			if call.IsError {
+				m.Class += " error"
+			}
+			tc := htmlToolCall{
+				Name:      call.Function.Name,
+				Arguments: indentJSON(call.Function.Arguments),
+			}
+			if call.Duration > 0 {
+				tc.Duration = call.Duration.String()
+			}
+			m.ToolCalls = append(m.ToolCalls, tc)
+		}
+		for _, img := range msg.Images {
+			m.Images = append(m.Images, template.URL( //nolint:gosec
+				"data:"+http.DetectContentType(img)+";base64,"+base64.StdEncoding.EncodeToString(img),
+			))
+		}
+		data.Messages = append(data.Messages, m)
+	}
+	if err := page.Execute(w, data); err != nil {
+		return fmt.Errorf("could not write html: %w", err)
+	}
+	return nil
+}
+
+func indentJSON(bts []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bts, "", "  "); err != nil {
+		return string(bts)
+	}
+	return buf.String()
+}
@@ -0,0 +1,311 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+)
+
+// ErrUnknownFormat happens when parsing data that isn't in any of the
+// supported formats.
+var ErrUnknownFormat = errors.New("unknown conversation format")
+
+// Parse reads the conversations in data, which may hold oi JSON exports,
+// OpenAI-style message arrays or {"messages": [...]} objects, or Open WebUI
+// chat exports. Any of them may be a single value, an array or one value per
+// line.
+func Parse(data []byte) ([]Conversation, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var result []Conversation
+	for {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+		}
+		convos, err := parseValue(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, convos...)
+	}
+	return result, nil
+}
+
+func parseValue(value json.RawMessage) ([]Conversation, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	if value[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+		}
+		if len(items) == 0 {
+			return nil, nil
+		}
+		var probe struct {
+			Role *string `json:"role"`
+		}
+		if err := json.Unmarshal(items[0], &probe); err == nil && probe.Role != nil {
+			messages, err := parseChatMessages(value)
+			if err != nil {
+				return nil, err
+			}
+			return []Conversation{{Messages: messages}}, nil
+		}
+		var result []Conversation
+		for _, item := range items {
+			convos, err := parseValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, convos...)
+		}
+		return result, nil
+	}
+
+	var probe struct {
+		Version  int             `json:"version"`
+		Messages json.RawMessage `json:"messages"`
+		Chat     json.RawMessage `json:"chat"`
+	}
+	if err := json.Unmarshal(value, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+	}
+	switch {
+	case probe.Chat != nil:
+		convo, err := parseOpenWebUI(value)
+		if err != nil {
+			return nil, err
+		}
+		return []Conversation{convo}, nil
+	case probe.Version > 0 && probe.Messages != nil:
+		var doc Document
+		if err := json.Unmarshal(value, &doc); err != nil {
+			return nil, fmt.Errorf("invalid oi export: %w", err)
+		}
+		return []Conversation{doc.Conversation()}, nil
+	case probe.Messages != nil:
+		messages, err := parseChatMessages(probe.Messages)
+		if err != nil {
+			return nil, err
+		}
+		return []Conversation{{Messages: messages}}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Conversation returns the conversation in the JSON export.
+func (d Document) Conversation() Conversation {
+	convo := Conversation{
+		ID:        d.ID,
+		Title:     d.Title,
+		API:       d.API,
+		Model:     d.Model,
+		UpdatedAt: d.UpdatedAt,
+		Messages:  make([]proto.Message, 0, len(d.Messages)),
+	}
+	for _, msg := range d.Messages {
+		m := proto.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+			Images:  msg.Images,
+			Metrics: msg.Metrics,
+		}
+		for _, call := range msg.ToolCalls {
+			var args []byte
+			var s string
+			if json.Unmarshal(call.Arguments, &s) == nil {
+				args = []byte(s)
+			} else if len(call.Arguments) > 0 {
+				var buf bytes.Buffer
+				_ = json.Compact(&buf, call.Arguments)
+				args = buf.Bytes()
+			}
+			m.ToolCalls = append(m.ToolCalls, proto.ToolCall{
+				ID: call.ID,
+				Function: proto.Function{
+					Name:      call.Name,
+					Arguments: args,
+				},
+				IsError:  call.IsError,
+				Duration: call.Duration,
+			})
+		}
+		convo.Messages = append(convo.Messages, m)
+	}
+	return convo
+}
+
+// importedMessage is a message in the OpenAI chat format. Content is either
+// a string or a list of parts.
+type importedMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []chatToolCall  `json:"tool_calls"`
+	ToolCallID string          `json:"tool_call_id"`
+}
+
+func parseChatMessages(data json.RawMessage) ([]proto.Message, error) {
+	var input []importedMessage
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("invalid messages: %w", err)
+	}
+	calls := map[string]proto.ToolCall{}
+	messages := make([]proto.Message, 0, len(input))
+	for _, in := range input {
+		content, err := messageContent(in.Content)
+		if err != nil {
+			return nil, err
+		}
+		msg := proto.Message{
+			Role:    in.Role,
+			Content: content,
+		}
+		for _, call := range in.ToolCalls {
+			tc := proto.ToolCall{
+				ID: call.ID,
+				Function: proto.Function{
+					Name:      call.Function.Name,
+					Arguments: []byte(call.Function.Arguments),
+				},
+			}
+			calls[call.ID] = tc
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		if in.ToolCallID != "" {
+			call, ok := calls[in.ToolCallID]
+			if !ok {
+				call = proto.ToolCall{ID: in.ToolCallID}
+			}
+			msg.ToolCalls = []proto.ToolCall{call}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// messageContent returns the text of a message content, which is either a
+// string or a list of parts of which only the text ones are kept.
+func messageContent(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return "", fmt.Errorf("invalid message content: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, part := range parts {
+		if part.Type != "text" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(part.Text)
+	}
+	return buf.String(), nil
+}
+
+// openWebUIChat is a chat in an Open WebUI export.
+type openWebUIChat struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	UpdatedAt int64  `json:"updated_at"`
+	Chat      struct {
+		Title    string             `json:"title"`
+		Models   []string           `json:"models"`
+		Messages []openWebUIMessage `json:"messages"`
+		History  struct {
+			CurrentID string                      `json:"currentId"`
+			Messages  map[string]openWebUIMessage `json:"messages"`
+		} `json:"history"`
+	} `json:"chat"`
+}
+
+type openWebUIMessage struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parentId"`
+	Role     string `json:"role"`
+	Content  string `json:"content"`
+	Model    string `json:"model"`
+}
+
+// parseOpenWebUI reads an Open WebUI chat. Chats are trees of messages, only
+// the branch that was last shown is imported.
+func parseOpenWebUI(data json.RawMessage) (Conversation, error) {
+	var chat openWebUIChat
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return Conversation{}, fmt.Errorf("invalid Open WebUI export: %w", err)
+	}
+
+	messages := chat.Chat.Messages
+	if history := chat.Chat.History; history.CurrentID != "" {
+		messages = nil
+		for id := history.CurrentID; id != ""; {
+			msg, ok := history.Messages[id]
+			if !ok || len(messages) > len(history.Messages) {
+				break
+			}
+			messages = append(messages, msg)
+			id = msg.ParentID
+		}
+		slices.Reverse(messages)
+	}
+
+	convo := Conversation{
+		Title: chat.Title,
+		API:   "openwebui",
+	}
+	if convo.Title == "" {
+		convo.Title = chat.Chat.Title
+	}
+	if len(chat.Chat.Models) > 0 {
+		convo.Model = chat.Chat.Models[0]
+	}
+	if chat.UpdatedAt > 0 {
+		convo.UpdatedAt = time.Unix(chat.UpdatedAt, 0).UTC()
+	}
+	for _, msg := range messages {
+		if msg.Model != "" {
+			convo.Model = msg.Model
+		}
+		convo.Messages = append(convo.Messages, proto.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	return convo, nil
+}
+
+// Hash identifies the conversation by the roles and contents of its
+// messages, so the same conversation imported twice can be recognized.
+func (c Conversation) Hash() string {
+	h := sha256.New()
+	for _, msg := range c.Messages {
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00%s\x00", msg.Role, len(msg.Content), msg.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, testConversation()))
+	convos, err := Parse(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, []Conversation{testConversation()}, convos)
+}
+
+func TestParseJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSONL, testConversation()))
+	require.NoError(t, Write(&buf, FormatJSONL, testConversation()))
+	convos, err := Parse(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, convos, 2)
+
+	want := testConversation().Messages
+	got := convos[0].Messages
+	require.Len(t, got, len(want))
+	for i := range want {
+		require.Equal(t, want[i].Role, got[i].Role)
+		require.Equal(t, want[i].Content, got[i].Content)
+	}
+	require.Equal(t, "builtin_run_command", got[3].ToolCalls[0].Function.Name)
+	require.Equal(t, testConversation().Hash(), convos[0].Hash())
+}
+
+func TestParseMessageArray(t *testing.T) {
+	convos, err := Parse([]byte(`[
+		{"role": "user", "content": [{"type": "text", "text": "hi"}, {"type": "image_url"}]},
+		{"role": "assistant", "content": "hello"}
+	]`))
+	require.NoError(t, err)
+	require.Equal(t, []Conversation{{Messages: []proto.Message{
+		{Role: proto.RoleUser, Content: "hi"},
+		{Role: proto.RoleAssistant, Content: "hello"},
+	}}}, convos)
+}
+
+func TestParseOpenWebUI(t *testing.T) {
+	convos, err := Parse([]byte(`[{
+		"id": "c1",
+		"title": "Greetings",
+		"updated_at": 1741964966,
+		"chat": {
+			"models": ["llama3.2"],
+			"history": {
+				"currentId": "m3",
+				"messages": {
+					"m1": {"id": "m1", "role": "user", "content": "hi"},
+					"m2": {"id": "m2", "parentId": "m1", "role": "assistant", "content": "discarded", "model": "llama3.2"},
+					"m3": {"id": "m3", "parentId": "m1", "role": "assistant", "content": "hello", "model": "qwen3"}
+				}
+			}
+		}
+	}]`))
+	require.NoError(t, err)
+	require.Equal(t, []Conversation{{
+		Title:     "Greetings",
+		API:       "openwebui",
+		Model:     "qwen3",
+		UpdatedAt: time.Unix(1741964966, 0).UTC(),
+		Messages: []proto.Message{
+			{Role: proto.RoleUser, Content: "hi"},
+			{Role: proto.RoleAssistant, Content: "hello"},
+		},
+	}}, convos)
+}
+
+func TestParseUnknown(t *testing.T) {
+	_, err := Parse([]byte(`{"foo": "bar"}`))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = Parse([]byte(`not json`))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
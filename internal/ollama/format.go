@@ -22,26 +22,35 @@ func fromMCPTools(mcps map[string][]mcp.Tool) []api.Tool {
 					Description: tool.Description,
 				},
 			}
-			_ = json.Unmarshal(tool.RawInputSchema, &t.Function.Parameters)
+			schema := tool.RawInputSchema
+			if schema == nil {
+				schema, _ = json.Marshal(tool.InputSchema)
+			}
+			_ = json.Unmarshal(schema, &t.Function.Parameters)
 			tools = append(tools, t)
 		}
 	}
 	return tools
 }
 
-func fromProtoMessages(input []proto.Message) []api.Message {
+func fromProtoMessages(input []proto.Message, vision bool) []api.Message {
 	messages := make([]api.Message, 0, len(input))
 	for _, msg := range input {
-		messages = append(messages, fromProtoMessage(msg))
+		messages = append(messages, fromProtoMessage(msg, vision))
 	}
 	return messages
 }
 
-func fromProtoMessage(input proto.Message) api.Message {
+func fromProtoMessage(input proto.Message, vision bool) api.Message {
 	m := api.Message{
 		Content: input.Content,
 		Role:    input.Role,
 	}
+	if vision {
+		for _, img := range input.Images {
+			m.Images = append(m.Images, api.ImageData(img))
+		}
+	}
 	for _, call := range input.ToolCalls {
 		var args api.ToolCallFunctionArguments
 		_ = json.Unmarshal(call.Function.Arguments, &args)
@@ -62,6 +71,9 @@ func toProtoMessage(in api.Message) proto.Message {
 		Role:    in.Role,
 		Content: in.Content,
 	}
+	for _, img := range in.Images {
+		msg.Images = append(msg.Images, []byte(img))
+	}
 	for _, call := range in.ToolCalls {
 		msg.ToolCalls = append(msg.ToolCalls, proto.ToolCall{
 			ID: strconv.Itoa(call.Function.Index),
@@ -73,3 +85,11 @@ func toProtoMessage(in api.Message) proto.Message {
 	}
 	return msg
 }
+
+func toProtoMetrics(in api.Metrics) *proto.Metrics {
+	return &proto.Metrics{
+		PromptTokens:     in.PromptEvalCount,
+		CompletionTokens: in.EvalCount,
+		Duration:         in.TotalDuration,
+	}
+}
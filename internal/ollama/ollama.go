@@ -5,11 +5,13 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 
 	"github.com/GuntuAshok/oi/internal/proto"
 	"github.com/GuntuAshok/oi/internal/stream"
 	"github.com/ollama/ollama/api"
+	modeltype "github.com/ollama/ollama/types/model"
 )
 
 var _ stream.Client = &Client{}
@@ -46,16 +48,67 @@ func New(config Config) (*Client, error) {
 	}, nil
 }
 
+// SupportsVision reports whether the given model accepts images.
+func (c *Client) SupportsVision(ctx context.Context, model string) bool {
+	resp, err := c.Show(ctx, &api.ShowRequest{Model: model})
+	if err != nil {
+		return false
+	}
+	return slices.Contains(resp.Capabilities, modeltype.CapabilityVision)
+}
+
 // Request implements stream.Client.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
-	b := true
 	s := &Stream{
 		toolCall: request.ToolCaller,
+		toolLoop: stream.NewToolLoop(request.ToolLimits),
+		vision:   request.Vision,
+	}
+	s.request = chatRequest(request, true)
+	s.messages = request.Messages
+	s.factory = func() {
+		s.done = false
+		s.err = nil
+		s.respCh = make(chan api.ChatResponse)
+		s.chatDone = make(chan struct{})
+		go func() {
+			defer close(s.chatDone)
+			if err := c.Chat(ctx, &s.request, s.fn); err != nil {
+				s.err = err
+			}
+		}()
 	}
+	s.factory()
+	return s
+}
+
+// Complete sends the request and waits for the whole answer.
+func (c *Client) Complete(ctx context.Context, request proto.Request) (proto.Message, error) {
+	body := chatRequest(request, false)
+	var msg api.Message
+	var metrics *proto.Metrics
+	if err := c.Chat(ctx, &body, func(resp api.ChatResponse) error {
+		if msg.Role == "" {
+			msg.Role = resp.Message.Role
+		}
+		msg.Content += resp.Message.Content
+		if resp.Done {
+			metrics = toProtoMetrics(resp.Metrics)
+		}
+		return nil
+	}); err != nil {
+		return proto.Message{}, err //nolint:wrapcheck
+	}
+	result := toProtoMessage(msg)
+	result.Metrics = metrics
+	return result, nil
+}
+
+func chatRequest(request proto.Request, stream bool) api.ChatRequest {
 	body := api.ChatRequest{
 		Model:    request.Model,
-		Messages: fromProtoMessages(request.Messages),
-		Stream:   &b,
+		Messages: fromProtoMessages(request.Messages, request.Vision),
+		Stream:   &stream,
 		Tools:    fromMCPTools(request.Tools),
 		Options:  map[string]any{},
 	}
@@ -72,20 +125,7 @@ func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stre
 	if request.TopP != nil {
 		body.Options["top_p"] = *request.TopP
 	}
-	s.request = body
-	s.messages = request.Messages
-	s.factory = func() {
-		s.done = false
-		s.err = nil
-		s.respCh = make(chan api.ChatResponse)
-		go func() {
-			if err := c.Chat(ctx, &s.request, s.fn); err != nil {
-				s.err = err
-			}
-		}()
-	}
-	s.factory()
-	return s
+	return body
 }
 
 // Stream ollama stream.
@@ -95,9 +135,13 @@ type Stream struct {
 	done     bool
 	factory  func()
 	respCh   chan api.ChatResponse
+	chatDone chan struct{}
 	message  api.Message
-	toolCall func(name string, data []byte) (string, error)
+	toolCall func(name string, data []byte) (proto.ToolResult, error)
+	toolLoop *stream.ToolLoop
+	vision   bool
 	messages []proto.Message
+	metrics  *proto.Metrics
 }
 
 func (s *Stream) fn(resp api.ChatResponse) error {
@@ -114,31 +158,50 @@ func (s *Stream) CallTools() []proto.ToolCallStatus {
     if s.done {
         // The stream just finished. Add the completed assistant message
         // (which might contain tool calls) to our histories.
-        s.messages = append(s.messages, toProtoMessage(s.message))
+        if s.toolLoop.Done() {
+            // Calls made past the tool limits are never answered, so they
+            // are left out of the history to keep it valid.
+            s.message.ToolCalls = nil
+        }
+        msg := toProtoMessage(s.message)
+        msg.Metrics = s.metrics
+        s.messages = append(s.messages, msg)
         s.request.Messages = append(s.request.Messages, s.message)
     }
 
     // Now, check if this completed message *actually* had any tool calls.
-    if len(s.message.ToolCalls) == 0 {
+    // Once the tool limits are hit the model is asked to answer without
+    // tools, so any call it still makes is ignored.
+    if len(s.message.ToolCalls) == 0 || s.toolLoop.Done() {
         // No tools to call. The conversation turn is truly over.
         // We do not reset s.done. It stays 'true'.
         return nil
     }
 
     // --- We have tools to call ---
-    statuses := make([]proto.ToolCallStatus, 0, len(s.message.ToolCalls))
+    calls := make([]stream.ToolCallRequest, 0, len(s.message.ToolCalls))
     for _, call := range s.message.ToolCalls {
-        msg, status := stream.CallTool(
-            strconv.Itoa(call.Function.Index),
-            call.Function.Name,
-            []byte(call.Function.Arguments.String()),
-            s.toolCall,
-        )
-
-        // Append the *tool result* (a "tool" role message) to the histories
-        s.request.Messages = append(s.request.Messages, fromProtoMessage(msg))
+        calls = append(calls, stream.ToolCallRequest{
+            ID:   strconv.Itoa(call.Function.Index),
+            Name: call.Function.Name,
+            Data: []byte(call.Function.Arguments.String()),
+        })
+    }
+    msgs, statuses := s.toolLoop.CallAll(calls, s.toolCall)
+
+    // Append the *tool results* ("tool" role messages) to the histories,
+    // in the order the model made the calls.
+    for _, msg := range msgs {
+        s.request.Messages = append(s.request.Messages, fromProtoMessage(msg, s.vision))
         s.messages = append(s.messages, msg)
-        statuses = append(statuses, status)
+    }
+
+    if s.toolLoop.EndRound() {
+        s.request.Tools = nil
+        s.request.Messages = append(s.request.Messages, api.Message{
+            Role:    "system",
+            Content: stream.ToolLimitNote,
+        })
     }
 
     // NOW that tools are called and results are in s.request.Messages,
@@ -178,9 +241,12 @@ func (s *Stream) Current() (proto.Chunk, error) {
 		s.message.ToolCalls = append(s.message.ToolCalls, resp.Message.ToolCalls...)
 		if resp.Done {
 			s.done = true
+			s.metrics = toProtoMetrics(resp.Metrics)
 		}
 		return chunk, nil
-	default:
+	case <-s.chatDone:
+		// Waiting for the next chunk instead of returning right away keeps
+		// callers from polling, which starves the chat on a single CPU.
 		return proto.Chunk{}, stream.ErrNoContent
 	}
 }
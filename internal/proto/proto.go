@@ -2,9 +2,12 @@
 package proto
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -22,15 +25,103 @@ type Chunk struct {
 	Content string
 }
 
+// ToolResult is the content returned by a tool call.
+type ToolResult struct {
+	// Content is the text sent back to the model.
+	Content string
+	// Images are raw images to be forwarded to vision-capable models.
+	Images [][]byte
+	// Resources are the URIs of embedded resources inlined into Content.
+	Resources []string
+	// Files are the paths of binary results saved to disk.
+	Files []string
+	// Structured is true if the tool returned structured content.
+	Structured bool
+}
+
+// Verbosity controls how much detail of tool calls is rendered.
+type Verbosity int
+
+// Verbosity levels.
+const (
+	// VerbosityNormal renders the tool names, errors and result summaries.
+	VerbosityNormal Verbosity = iota
+	// VerbosityArguments also renders the call arguments and timing.
+	VerbosityArguments
+	// VerbosityFull also renders the results.
+	VerbosityFull
+)
+
+var verbosityNames = []string{"normal", "arguments", "full"}
+
+func (v Verbosity) String() string {
+	if v < 0 || int(v) >= len(verbosityNames) {
+		return fmt.Sprintf("Verbosity(%d)", int(v))
+	}
+	return verbosityNames[v]
+}
+
+// ParseVerbosity parses a verbosity level name.
+func ParseVerbosity(s string) (Verbosity, error) {
+	for i, name := range verbosityNames {
+		if s == name {
+			return Verbosity(i), nil
+		}
+	}
+	return VerbosityNormal, fmt.Errorf("invalid verbosity %q, valid values are: %s", s, strings.Join(verbosityNames, ", "))
+}
+
+// maxResultLines is the number of lines of a tool result rendered with
+// [VerbosityFull], the rest is elided.
+const maxResultLines = 20
+
 // ToolCallStatus is the status of a tool call.
 type ToolCallStatus struct {
-	Name string
-	Err  error
+	Name      string
+	Arguments []byte
+	Duration  time.Duration
+	Err       error
+	Result    ToolResult
+	Verbosity Verbosity
 }
 
 func (c ToolCallStatus) String() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("\n> Ran tool: `%s`\n", c.Name))
+	sb.WriteString(fmt.Sprintf("\n> Ran tool: `%s`", c.Name))
+	if c.Verbosity >= VerbosityArguments && c.Duration > 0 {
+		sb.WriteString(fmt.Sprintf(" in %s", c.Duration.Round(time.Millisecond)))
+	}
+	sb.WriteByte('\n')
+	if c.Verbosity >= VerbosityArguments && len(c.Arguments) > 0 {
+		sb.WriteString(">\n> Arguments:\n")
+		if args, ok := prettyJSON(c.Arguments); ok {
+			writeQuotedBlock(&sb, "json", args)
+		} else {
+			writeQuotedBlock(&sb, "", args)
+		}
+	}
+	if returned := c.Result.summary(); returned != "" {
+		sb.WriteString("> Returned: " + returned + "\n")
+	}
+	for _, uri := range c.Result.Resources {
+		sb.WriteString(fmt.Sprintf("> Resource: `%s`\n", uri))
+	}
+	for _, path := range c.Result.Files {
+		sb.WriteString(fmt.Sprintf("> Saved: `%s`\n", path))
+	}
+	if c.Verbosity >= VerbosityFull && c.Err == nil && c.Result.Content != "" {
+		sb.WriteString(">\n> Result:\n")
+		lines := strings.Split(strings.TrimRight(c.Result.Content, "\n"), "\n")
+		elided := 0
+		if len(lines) > maxResultLines {
+			elided = len(lines) - maxResultLines
+			lines = lines[:maxResultLines]
+		}
+		writeQuotedBlock(&sb, "", strings.Join(lines, "\n"))
+		if elided > 0 {
+			sb.WriteString(fmt.Sprintf("> *%d more lines*\n", elided))
+		}
+	}
 	if c.Err != nil {
 		sb.WriteString(">\n> *Failed*:\n> ```\n")
 		for line := range strings.SplitSeq(c.Err.Error(), "\n") {
@@ -42,11 +133,52 @@ func (c ToolCallStatus) String() string {
 	return sb.String()
 }
 
+// writeQuotedBlock writes s as a fenced code block inside a quote.
+func writeQuotedBlock(sb *strings.Builder, lang, s string) {
+	sb.WriteString("> ```" + lang + "\n")
+	for line := range strings.SplitSeq(s, "\n") {
+		sb.WriteString("> " + line + "\n")
+	}
+	sb.WriteString("> ```\n")
+}
+
+// prettyJSON indents JSON data, returning it as is if it isn't valid JSON.
+func prettyJSON(data []byte) (string, bool) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data), false
+	}
+	return buf.String(), true
+}
+
+func (r ToolResult) summary() string {
+	var parts []string
+	if n := len(r.Images); n == 1 {
+		parts = append(parts, "1 image")
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("%d images", n))
+	}
+	if r.Structured {
+		parts = append(parts, "structured content")
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Message is a message in the conversation.
 type Message struct {
 	Role      string
 	Content   string
+	Images    [][]byte
 	ToolCalls []ToolCall
+	// Metrics of the generation, only set on assistant messages.
+	Metrics *Metrics
+}
+
+// Metrics are the usage statistics reported for a generated message.
+type Metrics struct {
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	Duration         time.Duration `json:"duration_ns"`
 }
 
 // ToolCall is a tool call in a message.
@@ -54,6 +186,8 @@ type ToolCall struct {
 	ID       string
 	Function Function
 	IsError  bool
+	// Duration is how long the tool took to run.
+	Duration time.Duration
 }
 
 // Function is the function signature of a tool call.
@@ -75,13 +209,35 @@ type Request struct {
 	Stop           []string
 	MaxTokens      *int64
 	ResponseFormat *string
-	ToolCaller     func(name string, data []byte) (string, error)
+	ToolCaller     func(name string, data []byte) (ToolResult, error)
+	ToolLimits     ToolLimits
+	Vision         bool
+}
+
+// ToolLimits bounds the tool calls the model can make in a single turn.
+// Zero values mean no limit.
+type ToolLimits struct {
+	// MaxRounds is the maximum number of rounds of tool calls.
+	MaxRounds int
+	// MaxParallel is the maximum number of tool calls run concurrently.
+	MaxParallel int
+	// MaxResultSize is the maximum size of a tool result, in bytes.
+	MaxResultSize int
+	// Truncation is how results over MaxResultSize are truncated: head keeps
+	// the beginning, tail keeps the end and middle keeps both.
+	Truncation string
 }
 
 // Conversation is a conversation.
 type Conversation []Message
 
 func (cc Conversation) String() string {
+	return cc.Render(VerbosityNormal)
+}
+
+// Render renders the conversation as markdown, with tool calls rendered with
+// the given verbosity.
+func (cc Conversation) Render(verbosity Verbosity) string {
 	var sb strings.Builder
 	for _, msg := range cc {
 		if msg.Content == "" {
@@ -95,10 +251,16 @@ func (cc Conversation) String() string {
 		case RoleTool:
 			for _, tool := range msg.ToolCalls {
 				s := ToolCallStatus{
-					Name: tool.Function.Name,
+					Name:      tool.Function.Name,
+					Arguments: tool.Function.Arguments,
+					Duration:  tool.Duration,
+					Result:    ToolResult{Images: msg.Images},
+					Verbosity: verbosity,
 				}
 				if tool.IsError {
 					s.Err = errors.New(msg.Content)
+				} else {
+					s.Result.Content = msg.Content
 				}
 				sb.WriteString(s.String())
 			}
@@ -1,13 +1,26 @@
 package proto
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/x/exp/golden"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStringer(t *testing.T) {
-	messages := []Message{
+	golden.RequireEqual(t, []byte(Conversation(testMessages()).String()))
+}
+
+func TestRenderFull(t *testing.T) {
+	golden.RequireEqual(t, []byte(Conversation(testMessages()).Render(VerbosityFull)))
+}
+
+func testMessages() []Message {
+	return []Message{
 		{
 			Role:    RoleSystem,
 			Content: "you are a medieval king",
@@ -30,6 +43,7 @@ func TestStringer(t *testing.T) {
 						Name:      "myfunc",
 						Arguments: []byte(`{"a":"b"}`),
 					},
+					Duration: 1234 * time.Millisecond,
 				},
 			},
 		},
@@ -46,6 +60,54 @@ func TestStringer(t *testing.T) {
 			Content: "something from an assistant",
 		},
 	}
+}
+
+func TestToolCallStatusStringer(t *testing.T) {
+	status := ToolCallStatus{
+		Name: "myfunc",
+		Result: ToolResult{
+			Images:     [][]byte{[]byte("a"), []byte("b")},
+			Resources:  []string{"file:///tmp/notes.txt"},
+			Files:      []string{"/tmp/oi/myfunc-123.wav"},
+			Structured: true,
+		},
+	}
+
+	golden.RequireEqual(t, []byte(status.String()))
+}
+
+func TestToolCallStatusStringerVerbosity(t *testing.T) {
+	var lines []string
+	for i := range 25 {
+		lines = append(lines, fmt.Sprintf("line %d", i+1))
+	}
+	status := ToolCallStatus{
+		Name:      "myfunc",
+		Arguments: []byte(`{"path":"a.txt","n":[1,2]}`),
+		Duration:  42 * time.Millisecond,
+		Result:    ToolResult{Content: strings.Join(lines, "\n")},
+	}
+
+	for _, v := range []Verbosity{VerbosityNormal, VerbosityArguments, VerbosityFull} {
+		t.Run(v.String(), func(t *testing.T) {
+			status.Verbosity = v
+			golden.RequireEqual(t, []byte(status.String()))
+		})
+	}
+
+	t.Run("failed", func(t *testing.T) {
+		status.Verbosity = VerbosityFull
+		status.Arguments = []byte("not json")
+		status.Err = errors.New("boom")
+		golden.RequireEqual(t, []byte(status.String()))
+	})
+}
+
+func TestParseVerbosity(t *testing.T) {
+	v, err := ParseVerbosity("arguments")
+	require.NoError(t, err)
+	require.Equal(t, VerbosityArguments, v)
 
-	golden.RequireEqual(t, []byte(Conversation(messages).String()))
+	_, err = ParseVerbosity("loud")
+	require.Error(t, err)
 }
@@ -4,6 +4,7 @@ package stream
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/GuntuAshok/oi/internal/proto"
 )
@@ -45,19 +46,24 @@ type Stream interface {
 func CallTool(
 	id, name string,
 	data []byte,
-	caller func(name string, data []byte) (string, error),
+	caller func(name string, data []byte) (proto.ToolResult, error),
 ) (proto.Message, proto.ToolCallStatus) {
-	content, err := caller(name, data)
+	start := time.Now()
+	result, err := caller(name, data)
+	duration := time.Since(start)
+	content := result.Content
 	if content == "" && err != nil {
 		content = err.Error()
 	}
 	return proto.Message{
 			Role:    proto.RoleTool,
 			Content: content,
+			Images:  result.Images,
 			ToolCalls: []proto.ToolCall{
 				{
-					ID:      id,
-					IsError: err != nil,
+					ID:       id,
+					IsError:  err != nil,
+					Duration: duration,
 					Function: proto.Function{
 						Name:      name,
 						Arguments: data,
@@ -66,7 +72,10 @@ func CallTool(
 			},
 		},
 		proto.ToolCallStatus{
-			Name: name,
-			Err:  err,
+			Name:      name,
+			Arguments: data,
+			Duration:  duration,
+			Err:       err,
+			Result:    result,
 		}
 }
@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"golang.org/x/sync/errgroup"
+)
+
+// Truncation strategies for tool results.
+const (
+	TruncateHead   = "head"
+	TruncateMiddle = "middle"
+	TruncateTail   = "tail"
+)
+
+// ErrRepeatedCall happens when the model makes a tool call identical to one
+// it already made in the same turn.
+var ErrRepeatedCall = errors.New("identical tool call already made in this turn, use its previous result")
+
+// ToolLimitNote is sent to the model once the tool calling limits are hit,
+// before it is asked for a final answer without tools.
+const ToolLimitNote = "The tool calling limit was reached. Do not call any more tools, answer with the information gathered so far."
+
+// ToolLoop enforces [proto.ToolLimits] over the tool calls of a single turn.
+type ToolLoop struct {
+	limits proto.ToolLimits
+	rounds int
+	seen   map[string]struct{}
+	done   bool
+}
+
+// NewToolLoop creates a new [ToolLoop].
+func NewToolLoop(limits proto.ToolLimits) *ToolLoop {
+	return &ToolLoop{
+		limits: limits,
+		seen:   map[string]struct{}{},
+	}
+}
+
+// ToolCallRequest is a tool call requested by the model.
+type ToolCallRequest struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+// Call calls a single tool, see [ToolLoop.CallAll].
+func (l *ToolLoop) Call(
+	id, name string,
+	data []byte,
+	caller func(name string, data []byte) (proto.ToolResult, error),
+) (proto.Message, proto.ToolCallStatus) {
+	msgs, statuses := l.CallAll([]ToolCallRequest{{id, name, data}}, caller)
+	return msgs[0], statuses[0]
+}
+
+// CallAll calls tools like [CallTool], running up to the configured number of
+// calls concurrently and truncating their results. Messages and statuses are
+// returned in the order of the calls. Calls identical to a previous one are
+// not made again, and end the loop once the current round is over.
+func (l *ToolLoop) CallAll(
+	calls []ToolCallRequest,
+	caller func(name string, data []byte) (proto.ToolResult, error),
+) ([]proto.Message, []proto.ToolCallStatus) {
+	msgs := make([]proto.Message, len(calls))
+	statuses := make([]proto.ToolCallStatus, len(calls))
+
+	var wg errgroup.Group
+	if l.limits.MaxParallel > 0 {
+		wg.SetLimit(l.limits.MaxParallel)
+	}
+	for i, call := range calls {
+		key := call.Name + "\x00" + string(call.Data)
+		if _, ok := l.seen[key]; ok {
+			l.done = true
+			msgs[i], statuses[i] = CallTool(call.ID, call.Name, call.Data, func(string, []byte) (proto.ToolResult, error) {
+				return proto.ToolResult{}, ErrRepeatedCall
+			})
+			continue
+		}
+		l.seen[key] = struct{}{}
+
+		wg.Go(func() error {
+			msgs[i], statuses[i] = CallTool(call.ID, call.Name, call.Data, func(name string, data []byte) (proto.ToolResult, error) {
+				result, err := caller(name, data)
+				if result.Content == "" && err != nil {
+					// errors become the content of the tool message, and
+					// are as large as the server wants.
+					result.Content = err.Error()
+				}
+				result.Content = Truncate(result.Content, l.limits)
+				return result, err
+			})
+			return nil
+		})
+	}
+	_ = wg.Wait()
+	return msgs, statuses
+}
+
+// EndRound marks the end of a round of tool calls, and reports whether the
+// limits were hit, in which case the model must answer without tools.
+func (l *ToolLoop) EndRound() bool {
+	l.rounds++
+	if l.limits.MaxRounds > 0 && l.rounds >= l.limits.MaxRounds {
+		l.done = true
+	}
+	return l.done
+}
+
+// Done reports whether the limits were hit.
+func (l *ToolLoop) Done() bool { return l.done }
+
+// Truncate caps content at the maximum result size using the configured
+// strategy, telling the model how much was left out.
+func Truncate(content string, limits proto.ToolLimits) string {
+	limit := limits.MaxResultSize
+	if limit <= 0 || len(content) <= limit {
+		return content
+	}
+	note := fmt.Sprintf("[tool result truncated from %d to %d bytes]", len(content), limit)
+	switch limits.Truncation {
+	case TruncateTail:
+		return note + "\n" + content[runeStart(content, len(content)-limit):]
+	case TruncateMiddle:
+		head := runeStart(content, limit/2) //nolint:mnd
+		tail := runeStart(content, len(content)-(limit-head))
+		return content[:head] + "\n" + note + "\n" + content[tail:]
+	default:
+		return content[:runeStart(content, limit)] + "\n" + note
+	}
+}
+
+// runeStart moves i back to the start of the rune it points into, so content
+// is never cut in the middle of a character.
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
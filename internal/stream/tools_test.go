@@ -0,0 +1,129 @@
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	content := strings.Repeat("a", 10) + strings.Repeat("b", 10)
+	note := "[tool result truncated from 20 to 10 bytes]"
+
+	t.Run("under limit", func(t *testing.T) {
+		require.Equal(t, content, Truncate(content, proto.ToolLimits{MaxResultSize: 20}))
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		require.Equal(t, content, Truncate(content, proto.ToolLimits{}))
+	})
+
+	t.Run("head", func(t *testing.T) {
+		require.Equal(t, "aaaaaaaaaa\n"+note, Truncate(content, proto.ToolLimits{
+			MaxResultSize: 10,
+			Truncation:    TruncateHead,
+		}))
+	})
+
+	t.Run("tail", func(t *testing.T) {
+		require.Equal(t, note+"\nbbbbbbbbbb", Truncate(content, proto.ToolLimits{
+			MaxResultSize: 10,
+			Truncation:    TruncateTail,
+		}))
+	})
+
+	t.Run("middle", func(t *testing.T) {
+		require.Equal(t, "aaaaa\n"+note+"\nbbbbb", Truncate(content, proto.ToolLimits{
+			MaxResultSize: 10,
+			Truncation:    TruncateMiddle,
+		}))
+	})
+
+	t.Run("multibyte", func(t *testing.T) {
+		out := Truncate("ééé", proto.ToolLimits{MaxResultSize: 3})
+		require.True(t, strings.HasPrefix(out, "é\n"))
+	})
+}
+
+func TestToolLoop(t *testing.T) {
+	var calls int
+	caller := func(string, []byte) (proto.ToolResult, error) {
+		calls++
+		return proto.ToolResult{Content: "0123456789"}, nil
+	}
+
+	t.Run("max rounds", func(t *testing.T) {
+		loop := NewToolLoop(proto.ToolLimits{MaxRounds: 2})
+		loop.Call("1", "a", []byte(`{"n":1}`), caller)
+		require.False(t, loop.EndRound())
+		loop.Call("2", "a", []byte(`{"n":2}`), caller)
+		require.True(t, loop.EndRound())
+		require.True(t, loop.Done())
+	})
+
+	t.Run("repeated call", func(t *testing.T) {
+		calls = 0
+		loop := NewToolLoop(proto.ToolLimits{})
+		loop.Call("1", "a", []byte(`{}`), caller)
+		require.False(t, loop.EndRound())
+		msg, status := loop.Call("2", "a", []byte(`{}`), caller)
+		require.Equal(t, 1, calls)
+		require.ErrorIs(t, status.Err, ErrRepeatedCall)
+		require.True(t, msg.ToolCalls[0].IsError)
+		require.True(t, loop.EndRound())
+	})
+
+	t.Run("truncates results", func(t *testing.T) {
+		loop := NewToolLoop(proto.ToolLimits{MaxResultSize: 4, Truncation: TruncateHead})
+		msg, status := loop.Call("1", "a", nil, caller)
+		require.Equal(t, "0123\n[tool result truncated from 10 to 4 bytes]", msg.Content)
+		require.Equal(t, msg.Content, status.Result.Content)
+	})
+	t.Run("truncates errors", func(t *testing.T) {
+		loop := NewToolLoop(proto.ToolLimits{MaxResultSize: 4, Truncation: TruncateHead})
+		msg, status := loop.Call("1", "a", nil, func(string, []byte) (proto.ToolResult, error) {
+			return proto.ToolResult{}, errors.New("0123456789")
+		})
+		require.Equal(t, "0123\n[tool result truncated from 10 to 4 bytes]", msg.Content)
+		require.True(t, msg.ToolCalls[0].IsError)
+		require.EqualError(t, status.Err, "0123456789")
+	})
+	t.Run("parallel calls keep their order", func(t *testing.T) {
+		var running, peak atomic.Int32
+		loop := NewToolLoop(proto.ToolLimits{MaxParallel: 2})
+		calls := []ToolCallRequest{
+			{ID: "1", Name: "a", Data: []byte(`30`)},
+			{ID: "2", Name: "b", Data: []byte(`10`)},
+			{ID: "3", Name: "c", Data: []byte(`20`)},
+			{ID: "4", Name: "a", Data: []byte(`30`)},
+		}
+		msgs, statuses := loop.CallAll(calls, func(name string, data []byte) (proto.ToolResult, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			ms, _ := strconv.Atoi(string(data))
+			time.Sleep(time.Duration(ms) * time.Millisecond)
+			return proto.ToolResult{Content: name}, nil
+		})
+		require.LessOrEqual(t, peak.Load(), int32(2))
+		require.Len(t, msgs, 4)
+		for i, name := range []string{"a", "b", "c"} {
+			require.Equal(t, name, msgs[i].Content)
+			require.Equal(t, calls[i].ID, msgs[i].ToolCalls[0].ID)
+			require.NoError(t, statuses[i].Err)
+		}
+		require.ErrorIs(t, statuses[3].Err, ErrRepeatedCall)
+		require.True(t, loop.EndRound())
+	})
+}
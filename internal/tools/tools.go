@@ -0,0 +1,370 @@
+// Package tools implements built-in tools that do not need a MCP server.
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ServerName is the name built-in tools are exposed under, as if they were
+// provided by a MCP server.
+const ServerName = "builtin"
+
+// Tool names.
+const (
+	ReadFile      = "read_file"
+	ListDirectory = "list_directory"
+	Grep          = "grep"
+	WriteFile     = "write_file"
+	RunCommand    = "run_command"
+)
+
+// All is a special name that enables all built-in tools.
+const All = "all"
+
+var (
+	errOutsideRoot = errors.New("path is outside of the workspace root")
+	errNotAllowed  = errors.New("denied by the user")
+	errUnknownTool = errors.New("unknown tool")
+)
+
+// Config configures the built-in tools.
+type Config struct {
+	// Root is the workspace root, tools cannot access anything outside it.
+	Root string
+	// ShellTimeout bounds how long run_command may take.
+	ShellTimeout time.Duration
+	// MaxOutput is the maximum number of bytes a tool may return.
+	MaxOutput int
+	// Confirm asks the user whether a destructive tool call may proceed.
+	Confirm func(tool, summary string) bool
+}
+
+// Tools is a set of enabled built-in tools.
+type Tools struct {
+	cfg     Config
+	enabled []string
+}
+
+// Names returns the names of all built-in tools.
+func Names() []string {
+	return []string{ReadFile, ListDirectory, Grep, WriteFile, RunCommand}
+}
+
+// IsTool reports whether name is a built-in tool name.
+func IsTool(name string) bool {
+	return slices.Contains(Names(), name)
+}
+
+// New creates a new [Tools] with the given tools enabled.
+func New(cfg Config, enabled []string) (*Tools, error) {
+	if slices.Contains(enabled, All) {
+		enabled = Names()
+	}
+	for _, name := range enabled {
+		if !IsTool(name) {
+			return nil, fmt.Errorf("%w: %q", errUnknownTool, name)
+		}
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace root: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	cfg.Root = root
+	return &Tools{
+		cfg:     cfg,
+		enabled: enabled,
+	}, nil
+}
+
+// Definitions returns the MCP definitions of the enabled tools.
+func (t *Tools) Definitions() []mcp.Tool {
+	var result []mcp.Tool
+	for _, def := range definitions() {
+		if slices.Contains(t.enabled, def.Name) {
+			result = append(result, def)
+		}
+	}
+	return result
+}
+
+func definitions() []mcp.Tool {
+	return []mcp.Tool{
+		mcp.NewTool(
+			ReadFile,
+			mcp.WithDescription("Read the contents of a file in the workspace"),
+			mcp.WithString("path", mcp.Required(), mcp.Description("Path of the file, relative to the workspace root")),
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		mcp.NewTool(
+			ListDirectory,
+			mcp.WithDescription("List the entries of a directory in the workspace, directories end with a slash"),
+			mcp.WithString("path", mcp.Description("Path of the directory, relative to the workspace root, defaults to the root")),
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		mcp.NewTool(
+			Grep,
+			mcp.WithDescription("Search files in the workspace for lines matching a regular expression"),
+			mcp.WithString("pattern", mcp.Required(), mcp.Description("Regular expression (RE2 syntax) to search for")),
+			mcp.WithString("path", mcp.Description("File or directory to search in, relative to the workspace root, defaults to the root")),
+			mcp.WithString("glob", mcp.Description("Only search files whose name matches this glob, e.g. *.go")),
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		mcp.NewTool(
+			WriteFile,
+			mcp.WithDescription("Write content to a file in the workspace, creating or replacing it"),
+			mcp.WithString("path", mcp.Required(), mcp.Description("Path of the file, relative to the workspace root")),
+			mcp.WithString("content", mcp.Required(), mcp.Description("Full content of the file")),
+			mcp.WithDestructiveHintAnnotation(true),
+		),
+		mcp.NewTool(
+			RunCommand,
+			mcp.WithDescription("Run a shell command in the workspace root and return its combined output"),
+			mcp.WithString("command", mcp.Required(), mcp.Description("Command to run with sh -c")),
+			mcp.WithDestructiveHintAnnotation(true),
+		),
+	}
+}
+
+// Call calls the given tool with the given JSON arguments.
+func (t *Tools) Call(ctx context.Context, name string, data []byte) (string, error) {
+	if !slices.Contains(t.enabled, name) {
+		return "", fmt.Errorf("%w: %q", errUnknownTool, name)
+	}
+
+	var request mcp.CallToolRequest
+	if len(data) > 0 {
+		var args map[string]any
+		if err := json.Unmarshal(data, &args); err != nil {
+			return "", fmt.Errorf("%w: %s", err, string(data))
+		}
+		request.Params.Arguments = args
+	}
+
+	switch name {
+	case ReadFile:
+		return t.readFile(request)
+	case ListDirectory:
+		return t.listDirectory(request)
+	case Grep:
+		return t.grep(ctx, request)
+	case WriteFile:
+		return t.writeFile(request)
+	case RunCommand:
+		return t.runCommand(ctx, request)
+	}
+	return "", fmt.Errorf("%w: %q", errUnknownTool, name)
+}
+
+// resolve returns the absolute path of the given workspace relative path,
+// making sure it does not escape the workspace root, symlinks included.
+func (t *Tools) resolve(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.cfg.Root, path)
+	}
+	path = filepath.Clean(path)
+
+	// resolve symlinks on the longest existing prefix of the path, so files
+	// that do not exist yet can still be checked.
+	resolved, rest := path, ""
+	for {
+		if r, err := filepath.EvalSymlinks(resolved); err == nil {
+			resolved = filepath.Join(r, rest)
+			break
+		}
+		parent := filepath.Dir(resolved)
+		if parent == resolved {
+			break
+		}
+		rest = filepath.Join(filepath.Base(resolved), rest)
+		resolved = parent
+	}
+
+	rel, err := filepath.Rel(t.cfg.Root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", errOutsideRoot, path)
+	}
+	return resolved, nil
+}
+
+func (t *Tools) truncate(s string) string {
+	if t.cfg.MaxOutput <= 0 || len(s) <= t.cfg.MaxOutput {
+		return s
+	}
+	return s[:t.cfg.MaxOutput] + fmt.Sprintf("\n[output truncated to %d bytes]", t.cfg.MaxOutput)
+}
+
+func (t *Tools) confirm(tool, summary string) error {
+	if t.cfg.Confirm == nil || !t.cfg.Confirm(tool, summary) {
+		return errNotAllowed
+	}
+	return nil
+}
+
+func (t *Tools) readFile(request mcp.CallToolRequest) (string, error) {
+	arg, err := request.RequireString("path")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	path, err := t.resolve(arg)
+	if err != nil {
+		return "", err
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return t.truncate(string(bts)), nil
+}
+
+func (t *Tools) listDirectory(request mcp.CallToolRequest) (string, error) {
+	path, err := t.resolve(request.GetString("path", "."))
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		sb.WriteString(entry.Name())
+		if entry.IsDir() {
+			sb.WriteByte('/')
+		}
+		sb.WriteByte('\n')
+	}
+	return t.truncate(sb.String()), nil
+}
+
+func (t *Tools) grep(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	pattern, err := request.RequireString("pattern")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	root, err := t.resolve(request.GetString("path", "."))
+	if err != nil {
+		return "", err
+	}
+	glob := request.GetString("glob", "")
+
+	var sb strings.Builder
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr
+		}
+		if err := ctx.Err(); err != nil {
+			return err //nolint:wrapcheck
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if glob != "" {
+			if ok, _ := filepath.Match(glob, d.Name()); !ok {
+				return nil
+			}
+		}
+		bts, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(bts, 0) >= 0 {
+			return nil //nolint:nilerr
+		}
+		rel, _ := filepath.Rel(t.cfg.Root, path)
+		scanner := bufio.NewScanner(bytes.NewReader(bts))
+		for line := 1; scanner.Scan(); line++ {
+			if re.Match(scanner.Bytes()) {
+				fmt.Fprintf(&sb, "%s:%d: %s\n", rel, line, scanner.Text())
+			}
+		}
+		if t.cfg.MaxOutput > 0 && sb.Len() > t.cfg.MaxOutput {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	if sb.Len() == 0 {
+		return "no matches found", nil
+	}
+	return t.truncate(sb.String()), nil
+}
+
+func (t *Tools) writeFile(request mcp.CallToolRequest) (string, error) {
+	arg, err := request.RequireString("path")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	content, err := request.RequireString("content")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	path, err := t.resolve(arg)
+	if err != nil {
+		return "", err
+	}
+	if err := t.confirm(WriteFile, fmt.Sprintf("write %d bytes to %s", len(content), path)); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return "", err //nolint:wrapcheck
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil { //nolint:mnd,gosec
+		return "", err //nolint:wrapcheck
+	}
+	return fmt.Sprintf("wrote %d bytes to %s", len(content), arg), nil
+}
+
+func (t *Tools) runCommand(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	command, err := request.RequireString("command")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	if err := t.confirm(RunCommand, command); err != nil {
+		return "", err
+	}
+	if t.cfg.ShellTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.cfg.ShellTimeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec
+	cmd.Dir = t.cfg.Root
+	// do not wait for children still holding the output open once the
+	// command itself is killed.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return t.truncate(string(out)), fmt.Errorf("command timed out after %s", t.cfg.ShellTimeout)
+	}
+	if err != nil {
+		return t.truncate(string(out)), err //nolint:wrapcheck
+	}
+	return t.truncate(string(out)), nil
+}
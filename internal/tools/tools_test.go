@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTools(t *testing.T, confirm func(string, string) bool, enabled ...string) (*Tools, string) {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello\nworld\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "b.go"), []byte("package b\n// hello\n"), 0o644))
+	tools, err := New(Config{
+		Root:         root,
+		ShellTimeout: time.Second,
+		MaxOutput:    1024,
+		Confirm:      confirm,
+	}, enabled)
+	require.NoError(t, err)
+	return tools, root
+}
+
+func TestNew(t *testing.T) {
+	t.Run("unknown tool", func(t *testing.T) {
+		_, err := New(Config{Root: t.TempDir()}, []string{"nope"})
+		require.ErrorIs(t, err, errUnknownTool)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		tools, err := New(Config{Root: t.TempDir()}, []string{All})
+		require.NoError(t, err)
+		require.Len(t, tools.Definitions(), len(Names()))
+	})
+
+	t.Run("some", func(t *testing.T) {
+		tools, err := New(Config{Root: t.TempDir()}, []string{ReadFile})
+		require.NoError(t, err)
+		defs := tools.Definitions()
+		require.Len(t, defs, 1)
+		require.Equal(t, ReadFile, defs[0].Name)
+	})
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("read file", func(t *testing.T) {
+		tools, _ := newTestTools(t, nil, ReadFile)
+		out, err := tools.Call(ctx, ReadFile, []byte(`{"path":"a.txt"}`))
+		require.NoError(t, err)
+		require.Equal(t, "hello\nworld\n", out)
+	})
+
+	t.Run("read file outside root", func(t *testing.T) {
+		tools, _ := newTestTools(t, nil, ReadFile)
+		_, err := tools.Call(ctx, ReadFile, []byte(`{"path":"../../etc/passwd"}`))
+		require.ErrorIs(t, err, errOutsideRoot)
+	})
+
+	t.Run("read file through symlink", func(t *testing.T) {
+		tools, root := newTestTools(t, nil, ReadFile)
+		require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(root, "link")))
+		_, err := tools.Call(ctx, ReadFile, []byte(`{"path":"link/secret"}`))
+		require.ErrorIs(t, err, errOutsideRoot)
+	})
+
+	t.Run("disabled tool", func(t *testing.T) {
+		tools, _ := newTestTools(t, nil, ReadFile)
+		_, err := tools.Call(ctx, ListDirectory, nil)
+		require.ErrorIs(t, err, errUnknownTool)
+	})
+
+	t.Run("list directory", func(t *testing.T) {
+		tools, _ := newTestTools(t, nil, ListDirectory)
+		out, err := tools.Call(ctx, ListDirectory, nil)
+		require.NoError(t, err)
+		require.Equal(t, "a.txt\nsub/\n", out)
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		tools, _ := newTestTools(t, nil, Grep)
+		out, err := tools.Call(ctx, Grep, []byte(`{"pattern":"hel+o","glob":"*.go"}`))
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join("sub", "b.go")+":2: // hello\n", out)
+	})
+
+	t.Run("write file denied", func(t *testing.T) {
+		tools, root := newTestTools(t, func(string, string) bool { return false }, WriteFile)
+		_, err := tools.Call(ctx, WriteFile, []byte(`{"path":"c.txt","content":"hi"}`))
+		require.ErrorIs(t, err, errNotAllowed)
+		require.NoFileExists(t, filepath.Join(root, "c.txt"))
+	})
+
+	t.Run("write file", func(t *testing.T) {
+		tools, root := newTestTools(t, func(string, string) bool { return true }, WriteFile)
+		_, err := tools.Call(ctx, WriteFile, []byte(`{"path":"new/c.txt","content":"hi"}`))
+		require.NoError(t, err)
+		bts, err := os.ReadFile(filepath.Join(root, "new", "c.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "hi", string(bts))
+	})
+
+	t.Run("run command", func(t *testing.T) {
+		tools, _ := newTestTools(t, func(string, string) bool { return true }, RunCommand)
+		out, err := tools.Call(ctx, RunCommand, []byte(`{"command":"cat a.txt"}`))
+		require.NoError(t, err)
+		require.Equal(t, "hello\nworld\n", out)
+	})
+
+	t.Run("run command timeout", func(t *testing.T) {
+		tools, _ := newTestTools(t, func(string, string) bool { return true }, RunCommand)
+		_, err := tools.Call(ctx, RunCommand, []byte(`{"command":"sleep 5"}`))
+		require.ErrorContains(t, err, "timed out")
+	})
+}
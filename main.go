@@ -13,7 +13,8 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/GuntuAshok/oi/internal/cache"
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/GuntuAshok/oi/internal/tools"
 	timeago "github.com/caarlos0/timea.go"
 	tea "github.com/charmbracelet/bubbletea"
 	glamour "github.com/charmbracelet/glamour/styles"
@@ -72,7 +73,13 @@ func runMods(ctx context.Context) error {
 	opts := []tea.ProgramOption{}
 
 	if !isInputTTY() || config.Raw {
-		opts = append(opts, tea.WithInput(nil))
+		if isOutputTTY() && !config.Raw && needsConfirmation(&config) {
+			// read tool call confirmations from the terminal, as stdin
+			// is being piped.
+			opts = append(opts, tea.WithInputTTY())
+		} else {
+			opts = append(opts, tea.WithInput(nil))
+		}
 	}
 	if isOutputTTY() && !config.Raw {
 		opts = append(opts, tea.WithOutput(os.Stderr))
@@ -80,16 +87,17 @@ func runMods(ctx context.Context) error {
 		opts = append(opts, tea.WithoutRenderer())
 	}
 
-	cache, err := cache.NewConversations(config.CachePath)
-	if err != nil {
-		return modsError{err, "Couldn't create cache."}
+	if err := unlockEncryption(&config); err != nil {
+		return err
 	}
 
 	// Pass a copy of the config to the instance
 	cfgCopy := config
-	mods := newMods(ctx, stderrRenderer(), &cfgCopy, db, cache)
+	mods := newMods(ctx, stderrRenderer(), &cfgCopy, db)
 	p := tea.NewProgram(mods, opts...)
 	m, err := p.Run()
+	// stop tool calls still running if the program was interrupted.
+	mods.cancel()
 	if err != nil {
 		return modsError{err, "Couldn't start Bubble Tea program."}
 	}
@@ -126,7 +134,7 @@ func runMods(ctx context.Context) error {
 	}
 
 	// Save if flagged (skipped for show via zeroed cacheWriteToID)
-	if mods.Config.cacheWriteToID != "" {
+	if mods.Config.cacheWriteToID != "" && !skipPipelineSave(mods.Config) {
 		return saveConversation(mods)
 	}
 	return nil
@@ -141,6 +149,7 @@ var (
 		Short:         "GPT on the command line. Built for pipelines.",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		Args:          cobra.ArbitraryArgs,
 		Example:       randomExample(),
 		// In main.go
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -160,12 +169,28 @@ var (
 				config.MCPTimeout = defaultConfig().MCPTimeout
 			}
 
+			if config.BuiltinTools.ShellTimeout == 0 {
+				config.BuiltinTools.ShellTimeout = defaultConfig().BuiltinTools.ShellTimeout
+			}
+
+			if config.BuiltinTools.MaxOutput == 0 {
+				config.BuiltinTools.MaxOutput = defaultConfig().BuiltinTools.MaxOutput
+			}
+
+			if config.ToolVerbosity != "" {
+				verbosity, err := proto.ParseVerbosity(config.ToolVerbosity)
+				if err != nil {
+					return modsError{err, "Invalid tool-verbosity setting."}
+				}
+				config.toolVerbosity = verbosity
+			}
+
 // Validate ambiguous no-arg flags: `--continue` must not be used by itself.
 // We allowed a NoOptDefVal sentinel ("__EMPTY__") to enable the --list combos,
 // but if the user invokes `--continue` alone it should be an error.
 const noOptSentinel = "__EMPTY__"
 if f := cmd.Flags().Lookup("continue"); f != nil {
-	if f.Changed && config.Continue == noOptSentinel && !config.List {
+	if f.Changed && config.Continue == noOptSentinel && !config.List && config.Search == "" {
 		return newUserErrorf("Missing argument for %s. Use %s <id|title|sha1> or %s for the last conversation.",
 			stderrStyles().Flag.Render("--continue"),
 			stderrStyles().Flag.Render("--continue"),
@@ -204,8 +229,33 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 				listRoles()
 				return nil
 			}
+			if cmd.Flags().Changed("search") && strings.TrimSpace(config.Search) == "" {
+				return newUserErrorf("Missing search query for %s.", stderrStyles().Flag.Render("--search"))
+			}
+
+			tags, err := normalizeTags(config.Tags)
+			if err != nil {
+				return err
+			}
+			config.Tags = tags
+			if config.Sort != "" && !slices.Contains(listSorts, config.Sort) {
+				return newUserErrorf("Invalid sort %q, valid values are: %s.", config.Sort, strings.Join(listSorts, ", "))
+			}
+			if config.Limit < 0 {
+				return newUserErrorf("Invalid limit %d.", config.Limit)
+			}
+			if cmd.Flags().Changed("model") {
+				config.listModel = config.Model
+			}
+			if cmd.Flags().Changed("api") {
+				config.listAPI = config.API
+			}
+			if config.Tree && !config.List && config.Search == "" {
+				return newUserErrorf("%s can only be used with %s.", stderrStyles().Flag.Render("--tree"), stderrStyles().Flag.Render("--list"))
+			}
+
 			// In rootCmd.RunE, replace the existing if config.List block with:
-			if config.List {
+			if config.List || config.Search != "" {
 				const noOptSentinel = "__EMPTY__" // same sentinel as above
 
 				comboShow := cmd.Flags().Changed("show") && (config.Show == "" || config.Show == noOptSentinel) && !config.ShowLast
@@ -255,10 +305,12 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 				if comboShow {
 					config.Show = selID
 					config.List = false // Reset for fall-through
+					config.Search = ""
 					// Fall through to runMods (load/print)
 				} else if comboContinue {
 					config.Continue = selID
 					config.List = false // Reset for fall-through + prompt
+					config.Search = ""
 					// Fall through to non-chat path (prompt for new message)
 				} else if comboDelete {
 					config.Delete = selected     // Overwrite empties with selected IDs
@@ -287,9 +339,7 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 			}
 
 			if config.MCPListTools {
-				ctx, cancel := context.WithTimeout(cmd.Context(), config.MCPTimeout)
-				defer cancel()
-				return mcpListTools(ctx)
+				return mcpListTools(cmd.Context())
 			}
 
 			if len(config.Delete) > 0 {
@@ -300,6 +350,29 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 				return deleteConversationOlderThan()
 			}
 
+			if config.Fork != "" {
+				id, err := forkConversation(config.Fork, config.Title)
+				if err != nil {
+					return err
+				}
+				if len(args) == 0 && !config.Chat && isInputTTY() {
+					return nil
+				}
+				// the fork has the title now, the prompt continues it by ID.
+				config.Continue = id
+				config.Title = ""
+			}
+
+			if config.Undo {
+				return undoLastTurn()
+			}
+			if config.Regenerate || config.EditLast {
+				if err := prepareRerun(cmd); err != nil {
+					return err
+				}
+				return runMods(cmd.Context())
+			}
+
 			// **NEW: Handle chat mode (your addition, unchanged)**
 			if config.Chat {
 				// First, let's select the model just once at the start.
@@ -313,6 +386,9 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 				// We don't want the TUI to re-print the prompt.
 				config.IncludePromptArgs = false
 
+				// MCP servers are kept running between the prompts of the chat.
+				defer startMcpSession(cmd.Context())()
+
 				// Now, enter a single, consistent loop for the whole conversation.
 				reader := bufio.NewReader(os.Stdin)
 				isFirstTurn := true
@@ -334,6 +410,12 @@ if f := cmd.Flags().Lookup("continue"); f != nil {
 					if trimmedPrompt == "" {
 						continue
 					}
+					if dir, ok := strings.CutPrefix(trimmedPrompt, "/cd "); ok {
+						if err := changeDir(cmd.Context(), strings.TrimSpace(dir)); err != nil {
+							handleError(err)
+						}
+						continue
+					}
 
 					// Prepare for the next turn
 					config.Prefix = trimmedPrompt
@@ -403,6 +485,18 @@ func initFlags() {
 	flags.StringVarP(&config.Continue, "continue", "c", "", stdoutStyles().FlagDesc.Render(help["continue"]))
 	flags.BoolVarP(&config.ContinueLast, "continue-last", "C", false, stdoutStyles().FlagDesc.Render(help["continue-last"]))
 	flags.BoolVarP(&config.List, "list", "l", config.List, stdoutStyles().FlagDesc.Render(help["list"]))
+	flags.StringVar(&config.Search, "search", "", stdoutStyles().FlagDesc.Render(help["search"]))
+	flags.StringVar(&config.Fork, "fork", "", stdoutStyles().FlagDesc.Render(help["fork"]))
+	flags.BoolVar(&config.Tree, "tree", false, stdoutStyles().FlagDesc.Render(help["tree"]))
+	flags.StringArrayVar(&config.Tags, "tag", nil, stdoutStyles().FlagDesc.Render(help["tag"]))
+	flags.BoolVar(&config.Pinned, "pinned", false, stdoutStyles().FlagDesc.Render(help["pinned"]))
+	flags.Var(newTimeFlag(&config.Since), "since", stdoutStyles().FlagDesc.Render(help["since"]))
+	flags.Var(newTimeFlag(&config.Until), "until", stdoutStyles().FlagDesc.Render(help["until"]))
+	flags.StringVar(&config.Sort, "sort", "", stdoutStyles().FlagDesc.Render(help["sort"]))
+	flags.IntVar(&config.Limit, "limit", 0, stdoutStyles().FlagDesc.Render(help["limit"]))
+	flags.BoolVar(&config.Regenerate, "regenerate", false, stdoutStyles().FlagDesc.Render(help["regenerate"]))
+	flags.BoolVar(&config.Undo, "undo", false, stdoutStyles().FlagDesc.Render(help["undo"]))
+	flags.BoolVar(&config.EditLast, "edit-last", false, stdoutStyles().FlagDesc.Render(help["edit-last"]))
 	flags.StringVarP(&config.Title, "title", "t", config.Title, stdoutStyles().FlagDesc.Render(help["title"]))
 	flags.StringArrayVarP(&config.Delete, "delete", "d", config.Delete, stdoutStyles().FlagDesc.Render(help["delete"]))
 	flags.Var(newDurationFlag(config.DeleteOlderThan, &config.DeleteOlderThan), "delete-older-than", stdoutStyles().FlagDesc.Render(help["delete-older-than"]))
@@ -423,7 +517,8 @@ func initFlags() {
 	}
 
 
-	flags.BoolVarP(&config.Quiet, "quiet", "q", config.Quiet, stdoutStyles().FlagDesc.Render(help["quiet"]))
+	// subcommands are quiet too, e.g. oi -q db migrate.
+	rootCmd.PersistentFlags().BoolVarP(&config.Quiet, "quiet", "q", config.Quiet, stdoutStyles().FlagDesc.Render(help["quiet"]))
 	flags.BoolVarP(&config.ShowHelp, "help", "h", false, stdoutStyles().FlagDesc.Render(help["help"]))
 	flags.BoolVarP(&config.Version, "version", "v", false, stdoutStyles().FlagDesc.Render(help["version"]))
 	flags.IntVar(&config.MaxRetries, "max-retries", config.MaxRetries, stdoutStyles().FlagDesc.Render(help["max-retries"]))
@@ -445,6 +540,12 @@ func initFlags() {
 	flags.BoolVar(&config.MCPList, "mcp-list", false, stdoutStyles().FlagDesc.Render(help["mcp-list"]))
 	flags.BoolVar(&config.MCPListTools, "mcp-list-tools", false, stdoutStyles().FlagDesc.Render(help["mcp-list-tools"]))
 	flags.StringArrayVar(&config.MCPDisable, "mcp-disable", nil, stdoutStyles().FlagDesc.Render(help["mcp-disable"]))
+	flags.BoolVar(&config.MCPSkipFailed, "mcp-skip-failed", config.MCPSkipFailed, stdoutStyles().FlagDesc.Render(help["mcp-skip-failed"]))
+	flags.BoolVar(&config.MCPRefresh, "mcp-refresh", false, stdoutStyles().FlagDesc.Render(help["mcp-refresh"]))
+	flags.StringArrayVar(&config.Roots, "root", nil, stdoutStyles().FlagDesc.Render(help["root"]))
+	flags.StringSliceVar(&config.Tools, "tools", nil, stdoutStyles().FlagDesc.Render(help["tools"]))
+	flags.IntVar(&config.MaxToolRounds, "max-tool-rounds", config.MaxToolRounds, stdoutStyles().FlagDesc.Render(help["max-tool-rounds"]))
+	flags.StringVar(&config.ToolVerbosity, "tool-verbosity", config.ToolVerbosity, stdoutStyles().FlagDesc.Render(help["tool-verbosity"]))
 	// Add the new --chat flag
 	flags.BoolVar(&config.Chat, "chat", false, stdoutStyles().FlagDesc.Render(help["chat"]))
 	flags.Lookup("prompt").NoOptDefVal = "-1"
@@ -453,12 +554,26 @@ func initFlags() {
 	flags.BoolVar(&memprofile, "memprofile", false, "Write memory profiles to CWD")
 	_ = flags.MarkHidden("memprofile")
 
-	for _, name := range []string{"show", "delete", "continue"} {
+	for _, name := range []string{"show", "delete", "continue", "fork"} {
 		_ = rootCmd.RegisterFlagCompletionFunc(name, func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			results, _ := db.Completions(toComplete)
 			return results, cobra.ShellCompDirectiveDefault
 		})
 	}
+	_ = rootCmd.RegisterFlagCompletionFunc("tools", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		names := append(tools.Names(), tools.All, toolsNone)
+		for name := range enabledMCPs() {
+			names = append(names, name)
+		}
+		return names, cobra.ShellCompDirectiveDefault
+	})
+	_ = rootCmd.RegisterFlagCompletionFunc("tool-verbosity", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			proto.VerbosityNormal.String(),
+			proto.VerbosityArguments.String(),
+			proto.VerbosityFull.String(),
+		}, cobra.ShellCompDirectiveDefault
+	})
 	_ = rootCmd.RegisterFlagCompletionFunc("role", func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return roleNames(toComplete), cobra.ShellCompDirectiveDefault
 	})
@@ -475,6 +590,22 @@ func initFlags() {
 		"mcp-list-tools",
 		"chat", // Add this line
 	)
+	// a fork can be continued in a chat, so fork is kept out of the group
+	// above.
+	rootCmd.MarkFlagsMutuallyExclusive(
+		"show",
+		"show-last",
+		"delete",
+		"delete-older-than",
+		"continue",
+		"continue-last",
+		"fork",
+		"reset-settings",
+		"mcp-list",
+		"mcp-list-tools",
+	)
+	rootCmd.MarkFlagsMutuallyExclusive("regenerate", "undo", "edit-last", "fork")
+	rootCmd.MarkFlagsMutuallyExclusive("regenerate", "undo", "edit-last", "chat")
 }
 
 func main() {
@@ -515,6 +646,21 @@ func main() {
 			os.Exit(1)
 		}
 		defer db.Close() //nolint:errcheck
+		db.encrypt = config.Encryption.Enabled
+		db.key = getEncryptionKey
+		// oi db shows the pending migrations and applies them itself.
+		if !isDBCmd(os.Args) {
+			if _, _, err := db.Migrate(); err != nil {
+				handleError(modsError{err, "Could not migrate the database."})
+				os.Exit(1)
+			}
+			if importsGob(os.Args) {
+				if err := importGobConversations(db, config.CachePath); err != nil {
+					handleError(modsError{err, "Could not import old conversations into the database."})
+					os.Exit(1)
+				}
+			}
+		}
 	}
 
 	if isCompletionCmd(os.Args) {
@@ -717,19 +863,11 @@ func deleteConversationOlderThan() error {
 		}
 	}
 
-	cache, err := cache.NewConversations(config.CachePath)
-	if err != nil {
-		return modsError{err, "Couldn't delete conversation."}
-	}
 	for _, c := range conversations {
 		if err := db.Delete(c.ID); err != nil {
 			return modsError{err, "Couldn't delete conversation."}
 		}
 
-		if err := cache.Delete(c.ID); err != nil {
-			return modsError{err, "Couldn't delete conversation."}
-		}
-
 		if !config.Quiet {
 			fmt.Fprintln(os.Stderr, "Conversation deleted:", c.ID[:sha1minLen])
 		}
@@ -770,14 +908,6 @@ func deleteConversation(convo *Conversation) error {
 		return modsError{err, "Couldn't delete conversation."}
 	}
 
-	cache, err := cache.NewConversations(config.CachePath)
-	if err != nil {
-		return modsError{err, "Couldn't delete conversation."}
-	}
-	if err := cache.Delete(convo.ID); err != nil {
-		return modsError{err, "Couldn't delete conversation."}
-	}
-
 	if !config.Quiet {
 		fmt.Fprintln(os.Stderr, "Conversation deleted:", convo.ID[:sha1minLen])
 	}
@@ -811,13 +941,23 @@ func makeOptions(conversations []Conversation) []huh.Option[string] {
 	for _, c := range conversations {
 		timea := stdoutStyles().Timeago.Render(timeago.Of(c.UpdatedAt))
 		left := stdoutStyles().SHA1.Render(c.ID[:sha1short])
-		right := stdoutStyles().ConversationList.Render(c.Title, timea)
+		tree := stdoutStyles().Comment.Render(c.treePrefix)
+		right := stdoutStyles().ConversationList.Render(tree+c.Title, timea)
 		if c.Model != nil {
 			right += stdoutStyles().Comment.Render(*c.Model)
 		}
 		if c.API != nil {
 			right += stdoutStyles().Comment.Render(" (" + *c.API + ")")
 		}
+		if meta := renderListMeta(c); meta != "" {
+			right += " " + meta
+		}
+		if c.Note != nil {
+			right += " " + stdoutStyles().Comment.Render(firstLine(*c.Note))
+		}
+		if c.Snippet != nil {
+			right += " " + stdoutStyles().Comment.Render(highlightSnippet(*c.Snippet, stdoutStyles().SearchMatch))
+		}
 		opts = append(opts, huh.NewOption(left+" "+right, c.ID))
 	}
 	return opts
@@ -825,12 +965,24 @@ func makeOptions(conversations []Conversation) []huh.Option[string] {
 
 func printList(conversations []Conversation) {
 	for _, conversation := range conversations {
+		tree := stdoutStyles().Comment.Render(conversation.treePrefix)
+		meta := renderListMeta(conversation)
+		if meta != "" {
+			meta = "\t" + meta
+		}
+		var snippet string
+		if conversation.Snippet != nil {
+			snippet = "\t" + highlightSnippet(*conversation.Snippet, stdoutStyles().SearchMatch)
+		}
 		_, _ = fmt.Fprintf(
 			os.Stdout,
-			"%s\t%s\t%s\n",
+			"%s\t%s%s\t%s%s%s\n",
 			stdoutStyles().SHA1.Render(conversation.ID[:sha1short]),
+			tree,
 			conversation.Title,
 			stdoutStyles().Timeago.Render(timeago.Of(conversation.UpdatedAt)),
+			meta,
+			snippet,
 		)
 	}
 }
@@ -848,10 +1000,13 @@ func allAreEmpty(ss []string) bool {
 // handleListSelect lists conversations and returns selected ID(s) if interactive
 // and a selection was made. For multi-delete, returns a slice. Returns nil/empty otherwise.
 func handleListSelect(multiDelete bool) ([]string, error) {
-	conversations, err := db.List()
+	conversations, err := listConversations()
 	if err != nil {
 		return nil, modsError{err, "Couldn't list saves."}
 	}
+	if config.Tree {
+		conversations = forkTree(conversations)
+	}
 
 	if len(conversations) == 0 {
 		fmt.Fprintln(os.Stderr, "No conversations found.")
@@ -885,10 +1040,14 @@ func handleListSelect(multiDelete bool) ([]string, error) {
 	} else {
 		// Single select (for plain --list or other non-delete combos)
 		var single string
+		title := "Conversations"
+		if config.Search != "" {
+			title = fmt.Sprintf("Conversations matching %q", config.Search)
+		}
 		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
-					Title("Conversations").
+					Title(title).
 					Value(&single).
 					Options(makeOptions(conversations)...),
 			),
@@ -926,9 +1085,14 @@ func saveConversation(mods *Mods) error {
 
 	id := mods.Config.cacheWriteToID
 	title := strings.TrimSpace(mods.Config.cacheWriteToTitle)
+	titleSource := titleFromUser
 
 	if sha1reg.MatchString(title) || title == "" {
-		title = firstLine(lastPrompt(mods.messages))
+		title, titleSource = firstLine(lastPrompt(mods.messages)), titleFromPrompt
+		// Continuing keeps the titles given or generated before.
+		if convo, err := db.Find(id); err == nil && convo.TitleSource != nil && *convo.TitleSource != titleFromPrompt {
+			title, titleSource = convo.Title, *convo.TitleSource
+		}
 	}
 
 	errReason := fmt.Sprintf(
@@ -937,16 +1101,22 @@ func saveConversation(mods *Mods) error {
 		stderrStyles().InlineCode.Render("--no-cache"),
 		stderrStyles().InlineCode.Render("NO_CACHE"),
 	)
-	cache, err := cache.NewConversations(mods.Config.CachePath)
+	alternative, err := db.SaveTurn(savedTurn{
+		ID:           id,
+		Title:        title,
+		TitleSource:  titleSource,
+		API:          mods.Config.API,
+		Model:        mods.Config.Model,
+		Messages:     mods.messages,
+		Tags:         mods.Config.Tags,
+		Replaced:     mods.Config.cacheDropLastTurn,
+		ReplacedFrom: lastTurnIndex(mods.messages),
+	})
 	if err != nil {
 		return modsError{err, errReason}
 	}
-	if err := cache.Write(id, &mods.messages); err != nil {
-		return modsError{err, errReason}
-	}
-	if err := db.Save(id, title, mods.Config.API, mods.Config.Model); err != nil {
-		_ = cache.Delete(id) // remove leftovers
-		return modsError{err, errReason}
+	if titleSource == titleFromPrompt && countTurns(mods.messages) == 1 {
+		titleInBackground(id)
 	}
 
 	if !mods.Config.Quiet {
@@ -956,8 +1126,13 @@ func saveConversation(mods *Mods) error {
 			stderrStyles().InlineCode.Render(id[:sha1short]),
 			stderrStyles().Comment.Render(title),
 		)
+		if alternative > 0 {
+			fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(
+				fmt.Sprintf("The previous response was kept as alternative %d.", alternative),
+			))
+		}
 	}
-	return nil
+	return enforceRetention(mods.Config, id)
 }
 
 func isNoArgs() bool {
@@ -968,6 +1143,7 @@ func isNoArgs() bool {
 		config.DeleteOlderThan == 0 &&
 		!config.ShowHelp &&
 		!config.List &&
+		config.Search == "" &&
 		!config.ListRoles &&
 		!config.MCPList &&
 		!config.MCPListTools &&
@@ -1046,6 +1222,27 @@ func isManCmd(args []string) bool {
 	return false
 }
 
+func isDBCmd(args []string) bool {
+	if len(args) <= 1 {
+		return false
+	}
+	// flags may come before the command, e.g. oi -q db status.
+	cmd, _, err := rootCmd.Find(args[1:])
+	return err == nil && (cmd == dbCmd || cmd.Parent() == dbCmd)
+}
+
+// importsGob reports whether the command reads or saves the conversation
+// history, so the conversations left in gob files are imported first. The
+// detached retitle only reads the conversation just saved, and the mcp
+// commands don't touch conversations.
+func importsGob(args []string) bool {
+	if len(args) <= 1 {
+		return true
+	}
+	cmd, _, err := rootCmd.Find(args[1:])
+	return err != nil || (cmd != retitleCmd && cmd != mcpCmd && cmd.Parent() != mcpCmd)
+}
+
 //nolint:mnd
 func isCompletionCmd(args []string) bool {
 	if len(args) <= 1 {
@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
 	"maps"
+	"mime"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/GuntuAshok/oi/internal/proto"
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"golang.org/x/sync/errgroup"
 )
@@ -48,7 +54,7 @@ func mcpList() {
 }
 
 func mcpListTools(ctx context.Context) error {
-	servers, err := mcpTools(ctx)
+	servers, err := mcpTools(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -61,13 +67,35 @@ func mcpListTools(ctx context.Context) error {
 	return nil
 }
 
-func mcpTools(ctx context.Context) (map[string][]mcp.Tool, error) {
+// mcpTools lists the selected tools of the enabled MCP servers. Servers none
+// of whose tools are selected are not started.
+func mcpTools(ctx context.Context, sel toolSelection) (map[string][]mcp.Tool, error) {
 	var mu sync.Mutex
 	var wg errgroup.Group
 	result := map[string][]mcp.Tool{}
 	for sname, server := range enabledMCPs() {
+		if !sel.includesServer(sname) {
+			continue
+		}
 		wg.Go(func() error {
+			if serverTools, ok := cachedMCPTools(sname, server); ok {
+				mu.Lock()
+				result[sname] = append(result[sname], serverTools...)
+				mu.Unlock()
+				return nil
+			}
 			serverTools, err := mcpToolsFor(ctx, sname, server)
+			if err != nil && config.MCPSkipFailed {
+				if !config.Quiet {
+					fmt.Fprintf(
+						os.Stderr,
+						"Skipping MCP server %s: %s\n",
+						stderrStyles().InlineCode.Render(sname),
+						err,
+					)
+				}
+				return nil
+			}
 			if errors.Is(err, context.DeadlineExceeded) {
 				return modsError{
 					err:    fmt.Errorf("timeout while listing tools for %q - make sure the configuration is correct. If your server requires a docker container, make sure it's running", sname),
@@ -80,6 +108,7 @@ func mcpTools(ctx context.Context) (map[string][]mcp.Tool, error) {
 					reason: "Could not list tools",
 				}
 			}
+			cacheMCPTools(sname, server, serverTools)
 			mu.Lock()
 			result[sname] = append(result[sname], serverTools...)
 			mu.Unlock()
@@ -89,25 +118,52 @@ func mcpTools(ctx context.Context) (map[string][]mcp.Tool, error) {
 	if err := wg.Wait(); err != nil {
 		return nil, err //nolint:wrapcheck
 	}
-	return result, nil
+	return sel.filter(result), nil
 }
 
-// initMcpClient creates and initializes an MCP client.
-func initMcpClient(ctx context.Context, server MCPServerConfig) (*client.Client, error) {
-	var cli *client.Client
-	var err error
+// newMcpClient creates an MCP client for the given server, stdio servers
+// are spawned right away and killed once ctx is done. The env-cmd and
+// headers-cmd commands of the server are bounded by its startup timeout.
+func newMcpClient(ctx context.Context, name string, server MCPServerConfig, hooks func() mcpHooks) (*client.Client, error) {
+	rctx, cancel := context.WithTimeout(ctx, server.startupTimeout())
+	defer cancel()
+	server, err := server.resolve(rctx)
+	if errors.Is(rctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s running env-cmd or headers-cmd: %w", server.startupTimeout(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	var opts []client.ClientOption
+	switch server.sampling() {
+	case samplingAsk, samplingAllow:
+		opts = append(opts, client.WithSamplingHandler(&mcpSampler{
+			server: name,
+			config: server,
+			hooks:  hooks,
+		}))
+	case samplingDeny:
+	default:
+		return nil, fmt.Errorf("invalid sampling setting: %q, valid values are: ask, allow, deny", server.Sampling)
+	}
+	opts = append(opts, client.WithRootsHandler(mcpRootsHandler{server}))
+
+	var trans transport.Interface
 
 	switch server.Type {
 	case "", "stdio":
-		cli, err = client.NewStdioMCPClient(
+		stdio := transport.NewStdio(
 			server.Command,
 			append(os.Environ(), server.Env...),
 			server.Args...,
 		)
+		err = stdio.Start(ctx)
+		trans = stdio
 	case "sse":
-		cli, err = client.NewSSEMCPClient(server.URL)
+		trans, err = transport.NewSSE(server.URL, transport.WithHeaders(server.Headers))
 	case "http":
-		cli, err = client.NewStreamableHttpClient(server.URL)
+		trans, err = transport.NewStreamableHTTP(server.URL, transport.WithHTTPHeaders(server.Headers))
 	default:
 		return nil, fmt.Errorf("unsupported MCP server type: %q, supported types are: stdio, sse, http", server.Type)
 	}
@@ -115,79 +171,386 @@ func initMcpClient(ctx context.Context, server MCPServerConfig) (*client.Client,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MCP client: %w", err)
 	}
+	return client.NewClient(trans, opts...), nil
+}
 
-	if err := cli.Start(ctx); err != nil {
+// initMcpClient creates and initializes an MCP client. hooks returns the
+// hooks of the prompt using the client.
+func initMcpClient(ctx context.Context, name string, server MCPServerConfig, hooks func() mcpHooks) (*client.Client, error) {
+	cli, err := newMcpClient(ctx, name, server, hooks)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := startMcpClient(ctx, cli, server); err != nil {
 		cli.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+	cli.OnNotification(func(n mcp.JSONRPCNotification) {
+		switch n.Method {
+		case methodNotificationProgress:
+			if progress := hooks().progress; progress != nil {
+				// the progress token is the name of the tool called.
+				tool, ok := n.Params.AdditionalFields["progressToken"].(string)
+				if !ok {
+					tool = name
+				}
+				progress(formatProgress(tool, n.Params.AdditionalFields))
+			}
+		case mcp.MethodNotificationToolsListChanged:
+			invalidateMCPTools(name, server)
+		}
+	})
+	return cli, nil
+}
+
+// mcpConn is an initialized MCP client, with the hooks of the prompt using
+// it, which change between the prompts of a chat.
+type mcpConn struct {
+	*client.Client
+
+	// calls holds the client for a single call at a time, as calls share the
+	// hooks.
+	calls sync.Mutex
+	mu    sync.RWMutex
+	hooks mcpHooks
+}
+
+func (c *mcpConn) getHooks() mcpHooks {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hooks
+}
+
+func (c *mcpConn) setHooks(hooks mcpHooks) {
+	c.mu.Lock()
+	c.hooks = hooks
+	c.mu.Unlock()
+}
+
+// mcpSession keeps the MCP clients open for a whole chat instead of a single
+// call, so servers keep their state between prompts and are told when the
+// roots change.
+var mcpSession struct {
+	sync.Mutex
+	ctx   context.Context //nolint:containedctx
+	conns map[string]*mcpConn
+}
+
+// startMcpSession keeps the MCP clients open until the returned function is
+// called, ctx bounds their lifetime.
+func startMcpSession(ctx context.Context) func() {
+	mcpSession.Lock()
+	mcpSession.ctx = ctx
+	mcpSession.conns = map[string]*mcpConn{}
+	mcpSession.Unlock()
+
+	return func() {
+		mcpSession.Lock()
+		defer mcpSession.Unlock()
+		for _, conn := range mcpSession.conns {
+			conn.calls.Lock()
+			if conn.Client != nil {
+				conn.Close() //nolint:errcheck,gosec
+				conn.Client = nil
+			}
+			conn.calls.Unlock()
+		}
+		mcpSession.conns = nil
+	}
+}
+
+// openMcpConn returns a client of the given server for a call, and the
+// function to call once done with it, with the error of the call if the
+// server failed to answer. Outside of a session, the client is closed once
+// done; in a session it is kept open unless it failed, in which case it is
+// started again by the next call.
+func openMcpConn(ctx context.Context, name string, server MCPServerConfig, hooks mcpHooks) (*mcpConn, func(error), error) {
+	mcpSession.Lock()
+	if mcpSession.conns == nil {
+		mcpSession.Unlock()
+		conn := &mcpConn{hooks: hooks}
+		cli, err := initMcpClient(ctx, name, server, conn.getHooks)
+		if err != nil {
+			return nil, nil, err
+		}
+		conn.Client = cli
+		return conn, func(error) {
+			cli.Close() //nolint:errcheck,gosec
+		}, nil
+	}
+	conn, ok := mcpSession.conns[name]
+	if !ok {
+		conn = &mcpConn{}
+		mcpSession.conns[name] = conn
+	}
+	sctx := mcpSession.ctx
+	mcpSession.Unlock()
+
+	conn.calls.Lock()
+	if conn.Client == nil {
+		cli, err := initMcpClient(sctx, name, server, conn.getHooks)
+		if err != nil {
+			conn.calls.Unlock()
+			return nil, nil, err
+		}
+		conn.Client = cli
+	}
+	conn.setHooks(hooks)
+	return conn, func(err error) {
+		conn.setHooks(mcpHooks{})
+		if err != nil {
+			conn.Close() //nolint:errcheck,gosec
+			conn.Client = nil
+		}
+		conn.calls.Unlock()
+	}, nil
+}
+
+// sessionMcpClients calls fn with each client open in the session.
+func sessionMcpClients(fn func(cli *client.Client)) {
+	mcpSession.Lock()
+	defer mcpSession.Unlock()
+	for _, conn := range mcpSession.conns {
+		conn.calls.Lock()
+		if conn.Client != nil {
+			fn(conn.Client)
+		}
+		conn.calls.Unlock()
+	}
+}
+
+// startMcpClient starts and initializes the given client.
+func startMcpClient(ctx context.Context, cli *client.Client, server MCPServerConfig) (*mcp.InitializeResult, error) {
+	// ctx bounds the lifetime of the client: stdio servers are spawned with
+	// it by newMcpClient and the streams of sse servers are opened with it,
+	// so only the initialization uses the startup timeout.
+	if err := cli.Start(ctx); err != nil {
 		return nil, fmt.Errorf("failed to start MCP client: %w", err)
 	}
 
-	if _, err := cli.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
-		cli.Close() //nolint:errcheck,gosec
+	ictx, cancel := context.WithTimeout(ctx, server.startupTimeout())
+	defer cancel()
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{
+		Name:    "oi",
+		Version: Version,
+	}
+	result, err := cli.Initialize(ictx, request)
+	if err != nil {
 		return nil, fmt.Errorf("failed to initialize MCP client: %w", err)
 	}
-
-	return cli, nil
+	return result, nil
 }
 
 func mcpToolsFor(ctx context.Context, name string, server MCPServerConfig) ([]mcp.Tool, error) {
-	cli, err := initMcpClient(ctx, server)
+	conn, done, err := openMcpConn(ctx, name, server, mcpHooks{})
 	if err != nil {
 		return nil, fmt.Errorf("could not setup %s: %w", name, err)
 	}
-	defer cli.Close() //nolint:errcheck
 
-	tools, err := cli.ListTools(ctx, mcp.ListToolsRequest{})
+	ctx, cancel := context.WithTimeout(ctx, server.callTimeout())
+	defer cancel()
+	tools, err := conn.ListTools(ctx, mcp.ListToolsRequest{})
+	done(err)
 	if err != nil {
 		return nil, fmt.Errorf("could not setup %s: %w", name, err)
 	}
 	return tools.Tools, nil
 }
 
-func toolCall(ctx context.Context, name string, data []byte) (string, error) {
-	sname, tool, ok := strings.Cut(name, "_")
-	if !ok {
-		return "", fmt.Errorf("mcp: invalid tool name: %q", name)
-	}
-	server, ok := config.MCPServers[sname]
+// mcpHooks connects MCP servers to the running prompt.
+type mcpHooks struct {
+	// progress reports progress notifications.
+	progress func(string)
+	// confirm asks the user to allow a server request.
+	confirm func(server, summary string) bool
+	// model is the model of the prompt, used to answer sampling requests.
+	model string
+	// vision is whether the model accepts images, they are saved to disk
+	// otherwise.
+	vision bool
+}
+
+// toolCall calls the given MCP tool, reporting any progress notifications
+// the server sends while it runs.
+func toolCall(ctx context.Context, name string, data []byte, hooks mcpHooks) (proto.ToolResult, error) {
+	sname, tool, ok := splitToolName(name)
 	if !ok {
-		return "", fmt.Errorf("mcp: invalid server name: %q", sname)
+		return proto.ToolResult{}, fmt.Errorf("mcp: invalid tool name: %q", name)
 	}
+	server := config.MCPServers[sname]
 	if !isMCPEnabled(sname) {
-		return "", fmt.Errorf("mcp: server is disabled: %q", sname)
+		return proto.ToolResult{}, fmt.Errorf("mcp: server is disabled: %q", sname)
 	}
-	client, err := initMcpClient(ctx, server)
-	if err != nil {
-		return "", fmt.Errorf("mcp: %w", err)
-	}
-	defer client.Close() //nolint:errcheck
-
 	var args map[string]any
 	if len(data) > 0 {
 		if err := json.Unmarshal(data, &args); err != nil {
-			return "", fmt.Errorf("mcp: %w: %s", err, string(data))
+			return proto.ToolResult{}, fmt.Errorf("mcp: %w: %s", err, string(data))
 		}
 	}
 
+	conn, done, err := openMcpConn(ctx, sname, server, hooks)
+	if err != nil {
+		return proto.ToolResult{}, fmt.Errorf("mcp: %w", err)
+	}
+
 	request := mcp.CallToolRequest{}
 	request.Params.Name = tool
 	request.Params.Arguments = args
-	result, err := client.CallTool(context.Background(), request)
+	request.Params.Meta = &mcp.Meta{ProgressToken: name}
+
+	timeout := server.callTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := conn.CallTool(ctx, request)
+	done(err)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return proto.ToolResult{}, fmt.Errorf("mcp: %s timed out after %s", name, timeout)
+	}
+	if err != nil {
+		return proto.ToolResult{}, fmt.Errorf("mcp: %w", err)
+	}
+
+	res, err := toolResult(name, result, hooks.vision)
 	if err != nil {
-		return "", fmt.Errorf("mcp: %w", err)
+		return proto.ToolResult{}, fmt.Errorf("mcp: %w", err)
+	}
+	if result.IsError {
+		return proto.ToolResult{}, errors.New(res.Content)
+	}
+	return res, nil
+}
+
+const methodNotificationProgress = "notifications/progress"
+
+// formatProgress formats the parameters of a MCP progress notification.
+func formatProgress(name string, params map[string]any) string {
+	s := name
+	progress, _ := params["progress"].(float64)
+	if total, _ := params["total"].(float64); total > 0 {
+		s += fmt.Sprintf(" %.0f%%", progress/total*100) //nolint:mnd
+	} else if progress > 0 {
+		s += fmt.Sprintf(" %g", progress)
+	}
+	if msg, _ := params["message"].(string); msg != "" {
+		s += ": " + msg
 	}
+	return s
+}
 
+func (s MCPServerConfig) startupTimeout() time.Duration {
+	if s.StartupTimeout > 0 {
+		return s.StartupTimeout
+	}
+	return config.MCPTimeout
+}
+
+func (s MCPServerConfig) callTimeout() time.Duration {
+	if s.CallTimeout > 0 {
+		return s.CallTimeout
+	}
+	return config.MCPTimeout
+}
+
+// toolResult converts the content of a MCP tool call result into a
+// [proto.ToolResult]: text is kept as is, images are forwarded to models with
+// vision, embedded resources are inlined, and audio, binary blobs and images
+// the model can't see are saved to disk with their path given to the model
+// instead.
+func toolResult(name string, result *mcp.CallToolResult, vision bool) (proto.ToolResult, error) {
+	var res proto.ToolResult
 	var sb strings.Builder
 	for _, content := range result.Content {
 		switch content := content.(type) {
 		case mcp.TextContent:
 			sb.WriteString(content.Text)
+		case mcp.ImageContent:
+			if !vision {
+				path, err := saveToolOutput(name, content.MIMEType, content.Data)
+				if err != nil {
+					return res, err
+				}
+				res.Files = append(res.Files, path)
+				fmt.Fprintf(&sb, "[Image (%s) saved to %s, the model can't see images]", content.MIMEType, path)
+				continue
+			}
+			img, err := base64.StdEncoding.DecodeString(content.Data)
+			if err != nil {
+				return res, fmt.Errorf("invalid image content: %w", err)
+			}
+			res.Images = append(res.Images, img)
+			fmt.Fprintf(&sb, "[Image %d (%s) attached]", len(res.Images), content.MIMEType)
+		case mcp.AudioContent:
+			path, err := saveToolOutput(name, content.MIMEType, content.Data)
+			if err != nil {
+				return res, err
+			}
+			res.Files = append(res.Files, path)
+			fmt.Fprintf(&sb, "[Audio (%s) saved to %s]", content.MIMEType, path)
+		case mcp.EmbeddedResource:
+			switch resource := content.Resource.(type) {
+			case mcp.TextResourceContents:
+				res.Resources = append(res.Resources, resource.URI)
+				fmt.Fprintf(&sb, "[Resource %s]\n%s", resource.URI, resource.Text)
+			case mcp.BlobResourceContents:
+				path, err := saveToolOutput(name, resource.MIMEType, resource.Blob)
+				if err != nil {
+					return res, err
+				}
+				res.Resources = append(res.Resources, resource.URI)
+				res.Files = append(res.Files, path)
+				fmt.Fprintf(&sb, "[Resource %s (%s) saved to %s]", resource.URI, resource.MIMEType, path)
+			}
+		case mcp.ResourceLink:
+			res.Resources = append(res.Resources, content.URI)
+			fmt.Fprintf(&sb, "[Resource link %s: %s]", content.URI, content.Name)
 		default:
 			sb.WriteString("[Non-text content]")
 		}
 	}
 
-	if result.IsError {
-		return "", errors.New(sb.String())
+	if result.StructuredContent != nil {
+		bts, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return res, fmt.Errorf("invalid structured content: %w", err)
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.Write(bts)
+		res.Structured = true
+	}
+
+	res.Content = sb.String()
+	return res, nil
+}
+
+// saveToolOutput decodes base64 data returned by a tool and writes it to the
+// configured output directory, returning the path of the new file.
+func saveToolOutput(name, mimeType, data string) (string, error) {
+	bts, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid binary content: %w", err)
+	}
+	dir := config.MCPOutputDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "oi")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil { //nolint:mnd
+		return "", fmt.Errorf("could not create output directory: %w", err)
+	}
+	var ext string
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	f, err := os.CreateTemp(dir, name+"-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("could not save tool output: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if _, err := f.Write(bts); err != nil {
+		return "", fmt.Errorf("could not save tool output: %w", err)
 	}
-	return sb.String(), nil
+	return f.Name(), nil
 }
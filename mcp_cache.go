@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/cache"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// mcpToolsCacheID identifies the cached tool listing of a server. It is
+// derived from the server configuration, so changing the configuration
+// invalidates the listing.
+func mcpToolsCacheID(name string, server MCPServerConfig) string {
+	bts, _ := json.Marshal(server)
+	sum := sha256.Sum256(append([]byte(name+"\x00"), bts...))
+	return "mcp-tools-" + hex.EncodeToString(sum[:])
+}
+
+func mcpToolsCache() (*cache.ExpiringCache[[]mcp.Tool], error) {
+	return cache.NewExpiring[[]mcp.Tool](config.CachePath) //nolint:wrapcheck
+}
+
+// cachedMCPTools returns the cached tool listing of the given server, if
+// caching is enabled and the listing has not expired.
+func cachedMCPTools(name string, server MCPServerConfig) ([]mcp.Tool, bool) {
+	if config.MCPToolsCacheTTL <= 0 || config.MCPRefresh {
+		return nil, false
+	}
+	c, err := mcpToolsCache()
+	if err != nil {
+		return nil, false
+	}
+	var tools []mcp.Tool
+	if err := c.Read(mcpToolsCacheID(name, server), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&tools) //nolint:wrapcheck
+	}); err != nil {
+		return nil, false
+	}
+	return tools, true
+}
+
+// cacheMCPTools caches the tool listing of the given server. Failures are
+// ignored, the tools are listed again on the next run.
+func cacheMCPTools(name string, server MCPServerConfig, tools []mcp.Tool) {
+	if config.MCPToolsCacheTTL <= 0 {
+		return
+	}
+	c, err := mcpToolsCache()
+	if err != nil {
+		return
+	}
+	expiresAt := time.Now().Add(config.MCPToolsCacheTTL).Unix()
+	_ = c.Write(mcpToolsCacheID(name, server), expiresAt, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(tools) //nolint:wrapcheck
+	})
+}
+
+// invalidateMCPTools removes the cached tool listing of the given server.
+func invalidateMCPTools(name string, server MCPServerConfig) {
+	c, err := mcpToolsCache()
+	if err != nil {
+		return
+	}
+	_ = c.Delete(mcpToolsCacheID(name, server))
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpDoctorJSON bool
+
+const stderrGracePeriod = 200 * time.Millisecond
+
+var mcpDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the enabled MCP servers start and report what they provide",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		reports := mcpDoctor(cmd.Context())
+		if mcpDoctorJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(reports); err != nil {
+				return modsError{err, "Could not encode the report."}
+			}
+		} else {
+			printMCPDoctor(reports)
+		}
+		for _, r := range reports {
+			if r.Error != "" {
+				return modsError{
+					err:    newUserErrorf("some MCP servers failed to start"),
+					reason: "MCP check failed.",
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	mcpDoctorCmd.Flags().BoolVar(&mcpDoctorJSON, "json", false, "Print the report as JSON")
+	mcpCmd.AddCommand(mcpDoctorCmd)
+}
+
+// mcpDoctorReport is the result of checking a single MCP server.
+type mcpDoctorReport struct {
+	Name            string        `json:"name"`
+	Transport       string        `json:"transport"`
+	ServerName      string        `json:"server_name,omitempty"`
+	ServerVersion   string        `json:"server_version,omitempty"`
+	ProtocolVersion string        `json:"protocol_version,omitempty"`
+	Capabilities    []string      `json:"capabilities,omitempty"`
+	Tools           int           `json:"tools"`
+	Latency         time.Duration `json:"latency_ns"`
+	Error           string        `json:"error,omitempty"`
+	Stderr          string        `json:"stderr,omitempty"`
+}
+
+// mcpDoctor checks all enabled MCP servers concurrently.
+func mcpDoctor(ctx context.Context) []mcpDoctorReport {
+	var names []string
+	servers := map[string]MCPServerConfig{}
+	for name, server := range enabledMCPs() {
+		names = append(names, name)
+		servers[name] = server
+	}
+
+	var wg sync.WaitGroup
+	reports := make([]mcpDoctorReport, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = checkMCPServer(ctx, name, servers[name])
+		}()
+	}
+	wg.Wait()
+	return reports
+}
+
+func checkMCPServer(ctx context.Context, name string, server MCPServerConfig) mcpDoctorReport {
+	report := mcpDoctorReport{
+		Name:      name,
+		Transport: server.Type,
+	}
+	if report.Transport == "" {
+		report.Transport = "stdio"
+	}
+
+	start := time.Now()
+	cli, err := newMcpClient(ctx, name, server, func() mcpHooks { return mcpHooks{} })
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	// stdio servers usually explain why they failed on stderr.
+	var stderr lockedBuffer
+	copied := make(chan struct{})
+	if r, ok := client.GetStderr(cli); ok {
+		go func() {
+			_, _ = io.Copy(&stderr, r)
+			close(copied)
+		}()
+	} else {
+		close(copied)
+	}
+	closeClient := sync.OnceFunc(func() {
+		cli.Close() //nolint:errcheck,gosec
+		select {
+		case <-copied:
+		case <-time.After(time.Second):
+		}
+	})
+	defer closeClient()
+
+	result, err := startMcpClient(ctx, cli, server)
+	report.Latency = time.Since(start)
+	if err != nil {
+		report.Error = err.Error()
+		// give a crashed server a moment to flush its stderr before the
+		// client closes the pipe.
+		select {
+		case <-copied:
+		case <-time.After(stderrGracePeriod):
+		}
+		closeClient()
+		report.Stderr = strings.TrimSpace(stderr.String())
+		return report
+	}
+
+	report.ServerName = result.ServerInfo.Name
+	report.ServerVersion = result.ServerInfo.Version
+	report.ProtocolVersion = result.ProtocolVersion
+	if result.Capabilities.Tools != nil {
+		report.Capabilities = append(report.Capabilities, "tools")
+	}
+	if result.Capabilities.Resources != nil {
+		report.Capabilities = append(report.Capabilities, "resources")
+	}
+	if result.Capabilities.Prompts != nil {
+		report.Capabilities = append(report.Capabilities, "prompts")
+	}
+
+	if result.Capabilities.Tools != nil {
+		lctx, cancel := context.WithTimeout(ctx, server.callTimeout())
+		defer cancel()
+		tools, err := cli.ListTools(lctx, mcp.ListToolsRequest{})
+		if err != nil {
+			report.Error = fmt.Sprintf("could not list tools: %s", err)
+			return report
+		}
+		report.Tools = len(tools.Tools)
+	}
+	return report
+}
+
+func printMCPDoctor(reports []mcpDoctorReport) {
+	if len(reports) == 0 {
+		fmt.Println("No MCP servers enabled.")
+		return
+	}
+	s := stdoutStyles()
+	for _, r := range reports {
+		if r.Error != "" {
+			fmt.Printf(
+				"%s %s %s\n",
+				s.ErrorHeader.String(),
+				s.AppName.Render(r.Name),
+				s.Timeago.Render("("+r.Transport+")"),
+			)
+			fmt.Println(s.ErrPadding.Render(s.ErrorDetails.Render(r.Error)))
+			if r.Stderr != "" {
+				fmt.Println(s.ErrPadding.Render(s.Comment.Render(r.Stderr)))
+			}
+			continue
+		}
+
+		fmt.Printf(
+			"%s %s %s\n",
+			s.Flag.Render("OK"),
+			s.AppName.Render(r.Name),
+			s.Timeago.Render("("+r.Transport+", "+r.Latency.Round(time.Millisecond).String()+")"),
+		)
+		server := strings.TrimSpace(r.ServerName + " " + r.ServerVersion)
+		if server == "" {
+			server = "unknown"
+		}
+		fmt.Println(s.ErrPadding.Render("Server:       " + server))
+		fmt.Println(s.ErrPadding.Render("Protocol:     " + r.ProtocolVersion))
+		capabilities := strings.Join(r.Capabilities, ", ")
+		if capabilities == "" {
+			capabilities = "none"
+		}
+		fmt.Println(s.ErrPadding.Render("Capabilities: " + capabilities))
+		fmt.Println(s.ErrPadding.Render(fmt.Sprintf("Tools:        %d", r.Tools)))
+	}
+}
+
+// lockedBuffer is a [bytes.Buffer] safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p) //nolint:wrapcheck
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers",
+	Args:  cobra.NoArgs,
+}
+
+var mcpImportOverwrite bool
+
+var mcpImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import MCP servers from a Claude Desktop, VS Code or Cursor configuration file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return mcpImport(args[0], mcpImportOverwrite)
+	},
+}
+
+func init() {
+	mcpImportCmd.Flags().BoolVar(&mcpImportOverwrite, "overwrite", false, "Replace servers that are already configured")
+	mcpCmd.AddCommand(mcpImportCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
+
+// externalMCPServer is a server definition as found in other MCP clients'
+// configuration files.
+type externalMCPServer struct {
+	Type    string            `json:"type"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// externalMCPConfig covers the different layouts used by other MCP clients:
+// Claude Desktop and Cursor use mcpServers, VS Code uses servers, either at
+// the top level of .vscode/mcp.json or under mcp in settings.json.
+type externalMCPConfig struct {
+	MCPServers map[string]externalMCPServer `json:"mcpServers"`
+	Servers    map[string]externalMCPServer `json:"servers"`
+	MCP        struct {
+		Servers map[string]externalMCPServer `json:"servers"`
+	} `json:"mcp"`
+}
+
+// readExternalMCPConfig reads MCP servers from another client's
+// configuration file.
+func readExternalMCPConfig(path string) (map[string]MCPServerConfig, error) {
+	bts, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var ext externalMCPConfig
+	if err := json.Unmarshal(stripJSONC(bts), &ext); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	result := map[string]MCPServerConfig{}
+	for _, servers := range []map[string]externalMCPServer{
+		ext.MCP.Servers,
+		ext.Servers,
+		ext.MCPServers,
+	} {
+		for name, server := range servers {
+			result[name] = server.toConfig()
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no MCP servers found in %s", path)
+	}
+	return result, nil
+}
+
+func (s externalMCPServer) toConfig() MCPServerConfig {
+	cfg := MCPServerConfig{
+		Command: s.Command,
+		Args:    s.Args,
+		URL:     s.URL,
+		Headers: s.Headers,
+	}
+	for _, k := range slices.Sorted(maps.Keys(s.Env)) {
+		cfg.Env = append(cfg.Env, k+"="+s.Env[k])
+	}
+	switch strings.ToLower(s.Type) {
+	case "sse":
+		cfg.Type = "sse"
+	case "http", "streamable-http", "streamablehttp":
+		cfg.Type = "http"
+	case "", "stdio":
+		if s.Command == "" && s.URL != "" {
+			cfg.Type = "http"
+		}
+	default:
+		cfg.Type = s.Type
+	}
+	return cfg
+}
+
+// loadMCPConfigFiles adds the servers defined in the mcp-config-files to the
+// configuration. Servers defined in mcp-servers take precedence. Relative
+// paths are relative to the directory of the settings file, and files that
+// can't be read are skipped with a warning so they don't break every command.
+func loadMCPConfigFiles(c *Config) {
+	for _, path := range c.MCPConfigFiles {
+		path = expandHome(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(c.SettingsPath), path)
+		}
+		servers, err := readExternalMCPConfig(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipped MCP configuration file: %v\n", err)
+			continue
+		}
+		if c.MCPServers == nil {
+			c.MCPServers = map[string]MCPServerConfig{}
+		}
+		for name, server := range servers {
+			if _, ok := c.MCPServers[name]; !ok {
+				c.MCPServers[name] = server
+			}
+		}
+	}
+}
+
+// mcpImport merges the servers of another client's configuration file into
+// the settings file, preserving its comments and layout.
+func mcpImport(path string, overwrite bool) error {
+	servers, err := readExternalMCPConfig(path)
+	if err != nil {
+		return modsError{err, "Could not import MCP servers."}
+	}
+
+	original, err := os.ReadFile(config.SettingsPath)
+	if err != nil {
+		return modsError{err, "Failed to read config file."}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(original, &root); err != nil {
+		return modsError{err, "Failed to parse config YAML."}
+	}
+	doc := &root
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		doc = root.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return modsError{errors.New("invalid yaml root"), "Config root is not a mapping node."}
+	}
+
+	serversNode := yamlMapValue(doc, "mcp-servers")
+	if serversNode == nil || serversNode.Kind != yaml.MappingNode {
+		newNode := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if !yamlReplaceMapValue(doc, "mcp-servers", newNode) {
+			doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "mcp-servers"}, newNode)
+		}
+		serversNode = newNode
+	}
+
+	var imported, skipped []string
+	for _, name := range slices.Sorted(maps.Keys(servers)) {
+		var value yaml.Node
+		if err := value.Encode(servers[name]); err != nil {
+			return modsError{err, "Could not import MCP servers."}
+		}
+		if yamlMapValue(serversNode, name) != nil {
+			if !overwrite {
+				skipped = append(skipped, name)
+				continue
+			}
+			yamlReplaceMapValue(serversNode, name, &value)
+		} else {
+			serversNode.Content = append(serversNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, &value)
+		}
+		imported = append(imported, name)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		_ = enc.Close()
+		return modsError{err, "Failed to encode updated YAML."}
+	}
+	if err := enc.Close(); err != nil {
+		return modsError{err, "Failed to close YAML encoder."}
+	}
+	if len(imported) > 0 {
+		if err := os.WriteFile(config.SettingsPath, buf.Bytes(), 0o644); err != nil { //nolint:gosec,mnd
+			return modsError{err, "Failed to write updated config."}
+		}
+	}
+
+	if !config.Quiet {
+		for _, name := range imported {
+			fmt.Fprintln(os.Stderr, "Imported MCP server:", stderrStyles().InlineCode.Render(name))
+		}
+		for _, name := range skipped {
+			fmt.Fprintf(
+				os.Stderr,
+				"Skipped MCP server %s: already configured, use %s to replace it.\n",
+				stderrStyles().InlineCode.Render(name),
+				stderrStyles().InlineCode.Render("--overwrite"),
+			)
+		}
+	}
+	return nil
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments,
+// as used by VS Code configuration files.
+func stripJSONC(in []byte) []byte {
+	var out []byte
+	var inString, escaped bool
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(in) && in[i+1] == '/':
+			for i < len(in) && in[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(in) && in[i+1] == '*':
+			i += 2
+			for i+1 < len(in) && (in[i] != '*' || in[i+1] != '/') {
+				i++
+			}
+			i++
+			continue
+		case c == ']' || c == '}':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+		}
+		if i < len(in) {
+			out = append(out, in[i])
+		}
+	}
+	return out
+}
+
+// expandHome expands a leading ~ in the given path to the user's home.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripJSONC(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in, want string
+	}{
+		"plain": {
+			in:   `{"a": [1, 2]}`,
+			want: `{"a": [1, 2]}`,
+		},
+		"line comment": {
+			in:   "{\n  // comment\n  \"a\": 1\n}",
+			want: "{\n  \n  \"a\": 1\n}",
+		},
+		"block comment": {
+			in:   `{/* comment */"a": /* more */ 1}`,
+			want: `{"a":  1}`,
+		},
+		"trailing commas": {
+			in:   "{\"a\": [1, 2,],\n  \"b\": 3,\n}",
+			want: "{\"a\": [1, 2],\n  \"b\": 3\n}",
+		},
+		"comments in strings": {
+			in:   `{"url": "https://example.com/*x*/", "c": "//"}`,
+			want: `{"url": "https://example.com/*x*/", "c": "//"}`,
+		},
+		"escaped quotes": {
+			in:   `{"a": "say \"//hi\"", "b": 1,}`,
+			want: `{"a": "say \"//hi\"", "b": 1}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, string(stripJSONC([]byte(tc.in))))
+		})
+	}
+}
+
+func TestReadExternalMCPConfig(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "mcp.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("claude desktop", func(t *testing.T) {
+		servers, err := readExternalMCPConfig(write(t, `{
+			"mcpServers": {
+				"fs": {
+					"command": "npx",
+					"args": ["-y", "@modelcontextprotocol/server-filesystem"],
+					"env": {"B": "2", "A": "1"}
+				},
+				"remote": {"url": "https://example.com/mcp"}
+			}
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, map[string]MCPServerConfig{
+			"fs": {
+				Command: "npx",
+				Args:    []string{"-y", "@modelcontextprotocol/server-filesystem"},
+				Env:     []string{"A=1", "B=2"},
+			},
+			"remote": {Type: "http", URL: "https://example.com/mcp"},
+		}, servers)
+	})
+
+	t.Run("vscode with comments", func(t *testing.T) {
+		servers, err := readExternalMCPConfig(write(t, `{
+			// servers of the workspace
+			"servers": {
+				"events": {
+					"type": "sse",
+					"url": "https://example.com/sse",
+					"headers": {"Authorization": "Bearer x"},
+				},
+			},
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, map[string]MCPServerConfig{
+			"events": {
+				Type:    "sse",
+				URL:     "https://example.com/sse",
+				Headers: map[string]string{"Authorization": "Bearer x"},
+			},
+		}, servers)
+	})
+
+	t.Run("vscode settings", func(t *testing.T) {
+		servers, err := readExternalMCPConfig(write(t, `{
+			"editor.fontSize": 14,
+			"mcp": {"servers": {"git": {"type": "stdio", "command": "uvx", "args": ["mcp-server-git"]}}}
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, map[string]MCPServerConfig{
+			"git": {Command: "uvx", Args: []string{"mcp-server-git"}},
+		}, servers)
+	})
+
+	t.Run("no servers", func(t *testing.T) {
+		_, err := readExternalMCPConfig(write(t, `{"editor.fontSize": 14}`))
+		require.ErrorContains(t, err, "no MCP servers found")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := readExternalMCPConfig(write(t, `{"servers": `))
+		require.ErrorContains(t, err, "could not parse")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := readExternalMCPConfig(filepath.Join(t.TempDir(), "missing.json"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestLoadMCPConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vscode"), 0o700))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, ".vscode", "mcp.json"),
+		[]byte(`{"servers": {"git": {"command": "uvx"}, "fs": {"command": "npx"}}}`),
+		0o600,
+	))
+
+	c := Config{
+		SettingsPath:   filepath.Join(dir, "oi.yml"),
+		MCPConfigFiles: []string{"missing.json", ".vscode/mcp.json"},
+		MCPServers:     map[string]MCPServerConfig{"fs": {Command: "fs-server"}},
+	}
+	loadMCPConfigFiles(&c)
+	require.Equal(t, map[string]MCPServerConfig{
+		"fs":  {Command: "fs-server"},
+		"git": {Command: "uvx"},
+	}, c.MCPServers)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// mcpRootsHandler tells a MCP server which directories it may operate on.
+type mcpRootsHandler struct {
+	server MCPServerConfig
+}
+
+// ListRoots implements client.RootsHandler.
+func (h mcpRootsHandler) ListRoots(context.Context, mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+	dirs, err := mcpRootDirs(h.server)
+	if err != nil {
+		return nil, err
+	}
+	result := &mcp.ListRootsResult{}
+	for _, dir := range dirs {
+		result.Roots = append(result.Roots, mcp.Root{
+			URI:  (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String(),
+			Name: filepath.Base(dir),
+		})
+	}
+	return result, nil
+}
+
+// mcpRootDirs returns the roots of the given server: the ones given with
+// --root, else the ones configured for the server, else the git root of the
+// current directory or the current directory itself.
+func mcpRootDirs(server MCPServerConfig) ([]string, error) {
+	dirs := config.Roots
+	if len(dirs) == 0 {
+		dirs = server.Roots
+	}
+	if len(dirs) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("could not get the current directory: %w", err)
+		}
+		if root, ok := gitRoot(wd); ok {
+			wd = root
+		}
+		return []string{wd}, nil
+	}
+
+	result := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(expandHome(dir))
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %q: %w", dir, err)
+		}
+		result = append(result, abs)
+	}
+	return result, nil
+}
+
+// gitRoot returns the root of the git repository dir is in.
+func gitRoot(dir string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// changeDir changes the current directory and tells the MCP servers running
+// in the chat session their roots changed.
+func changeDir(ctx context.Context, dir string) error {
+	if err := os.Chdir(expandHome(dir)); err != nil {
+		return modsError{err, "Could not change directory."}
+	}
+	sessionMcpClients(func(cli *client.Client) {
+		_ = cli.RootListChanges(ctx)
+	})
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Sampling settings of MCP servers.
+const (
+	samplingAsk   = "ask"
+	samplingAllow = "allow"
+	samplingDeny  = "deny"
+)
+
+var errSamplingDenied = errors.New("sampling request denied by the user")
+
+// sampling returns the sampling setting of the server, ask by default.
+func (s MCPServerConfig) sampling() string {
+	if s.Sampling == "" {
+		return samplingAsk
+	}
+	return s.Sampling
+}
+
+// mcpSampler answers the sampling requests of a MCP server with the local
+// model.
+type mcpSampler struct {
+	server string
+	config MCPServerConfig
+	hooks  func() mcpHooks
+}
+
+// samplingModel returns the model used to answer sampling requests: the
+// configured sampling model or else the model of the current prompt.
+func (s *mcpSampler) samplingModel() string {
+	if config.MCPSamplingModel != "" {
+		return config.MCPSamplingModel
+	}
+	if model := s.hooks().model; model != "" {
+		return model
+	}
+	return config.Model
+}
+
+// CreateMessage implements client.SamplingHandler.
+func (s *mcpSampler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	model := s.samplingModel()
+
+	var messages []proto.Message
+	if request.SystemPrompt != "" {
+		messages = append(messages, proto.Message{
+			Role:    proto.RoleSystem,
+			Content: request.SystemPrompt,
+		})
+	}
+	var vision bool
+	for _, msg := range request.Messages {
+		m := proto.Message{Role: string(msg.Role)}
+		switch content := msg.Content.(type) {
+		case mcp.TextContent:
+			m.Content = content.Text
+		case mcp.ImageContent:
+			img, err := base64.StdEncoding.DecodeString(content.Data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid image content: %w", err)
+			}
+			m.Images = append(m.Images, img)
+			vision = true
+		default:
+			return nil, fmt.Errorf("unsupported sampling content: %T", msg.Content)
+		}
+		messages = append(messages, m)
+	}
+
+	if s.config.sampling() != samplingAllow {
+		if confirm := s.hooks().confirm; confirm == nil || !confirm(s.server, samplingSummary(model, messages)) {
+			return nil, errSamplingDenied
+		}
+	}
+
+	client, err := serveOllamaClient()
+	if err != nil {
+		return nil, err
+	}
+	req := proto.Request{
+		Model:    model,
+		Messages: messages,
+		Stop:     request.StopSequences,
+		Vision:   vision,
+	}
+	if request.Temperature > 0 {
+		req.Temperature = &request.Temperature
+	}
+	answer, err := client.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("sampling failed: %w", err)
+	}
+
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{
+			Role:    mcp.RoleAssistant,
+			Content: mcp.NewTextContent(answer.Content),
+		},
+		Model:      model,
+		StopReason: "endTurn",
+	}, nil
+}
+
+// samplingSummary describes a sampling request for the approval prompt.
+func samplingSummary(model string, messages []proto.Message) string {
+	const maxPreview = 60
+	var last string
+	for _, msg := range messages {
+		if msg.Role == proto.RoleUser && msg.Content != "" {
+			last = msg.Content
+		}
+	}
+	last = strings.Join(strings.Fields(last), " ")
+	if runes := []rune(last); len(runes) > maxPreview {
+		last = string(runes[:maxPreview]) + "…"
+	}
+	return fmt.Sprintf("ask %s %q", model, last)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/cache"
+)
+
+// mcpVariable matches ${VAR} references in MCP server settings.
+var mcpVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolve returns the server configuration with the values of the env-cmd
+// and headers-cmd commands filled in, and the ${VAR} references in the
+// command, args, env, url and headers replaced.
+//
+// Variables are looked up in the values of env-cmd first and then in the
+// environment, so a secret obtained once can be used in several places.
+func (s MCPServerConfig) resolve(ctx context.Context) (MCPServerConfig, error) {
+	vars := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(s.EnvCmd)) {
+		value, err := mcpSecret(ctx, s.EnvCmd[name])
+		if err != nil {
+			return s, fmt.Errorf("env-cmd %s: %w", name, err)
+		}
+		vars[name] = value
+	}
+
+	var err error
+	expand := func(value string) string {
+		return mcpVariable.ReplaceAllStringFunc(value, func(ref string) string {
+			name := mcpVariable.FindStringSubmatch(ref)[1]
+			if v, ok := vars[name]; ok {
+				return v
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				return v
+			}
+			if err == nil {
+				err = fmt.Errorf("undefined variable %s", ref)
+			}
+			return ""
+		})
+	}
+
+	result := s
+	result.Command = expand(s.Command)
+	result.URL = expand(s.URL)
+	result.Args = make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		result.Args[i] = expand(arg)
+	}
+	result.Env = make([]string, 0, len(s.Env)+len(vars))
+	for _, env := range s.Env {
+		name, value, _ := strings.Cut(env, "=")
+		result.Env = append(result.Env, name+"="+expand(value))
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.EnvCmd)) {
+		result.Env = append(result.Env, name+"="+vars[name])
+	}
+	result.Headers = make(map[string]string, len(s.Headers)+len(s.HeadersCmd))
+	for name, value := range s.Headers {
+		result.Headers[name] = expand(value)
+	}
+	if err != nil {
+		return s, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.HeadersCmd)) {
+		value, err := mcpSecret(ctx, s.HeadersCmd[name])
+		if err != nil {
+			return s, fmt.Errorf("headers-cmd %s: %w", name, err)
+		}
+		result.Headers[name] = value
+	}
+	return result, nil
+}
+
+// mcpSecret returns the output of the given shell command, without the
+// trailing newline. Outputs are cached for mcp-secrets-cache-ttl so commands
+// such as password managers aren't run for every tool call.
+func mcpSecret(ctx context.Context, command string) (string, error) {
+	id := mcpSecretCacheID(command)
+	c, cerr := cache.NewExpiring[string](config.CachePath)
+	if cerr == nil && config.MCPSecretsCacheTTL > 0 {
+		var cached bytes.Buffer
+		if err := c.Read(id, func(r io.Reader) error {
+			_, err := io.Copy(&cached, r)
+			return err //nolint:wrapcheck
+		}); err == nil {
+			return cached.String(), nil
+		}
+	}
+
+	value, err := commandOutput(ctx, command)
+	if err != nil {
+		return "", err
+	}
+
+	if cerr == nil && config.MCPSecretsCacheTTL > 0 {
+		expiresAt := time.Now().Add(config.MCPSecretsCacheTTL).Unix()
+		_ = c.Write(id, expiresAt, func(w io.Writer) error {
+			_, err := io.WriteString(w, value)
+			return err //nolint:wrapcheck
+		})
+	}
+	return value, nil
+}
+
+// commandOutput runs the shell command and returns its output, without the
+// trailing newline.
+func commandOutput(ctx context.Context, command string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec
+	// processes started by the shell may keep the output open once it is
+	// killed, don't wait for them.
+	cmd.WaitDelay = time.Second
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf("%w", err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// mcpSecretCacheID identifies the cached output of a command.
+func mcpSecretCacheID(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return "mcp-secret-" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/ollama"
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/GuntuAshok/oi/internal/stream"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cobra"
+)
+
+var mcpServeAddr string
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "mcp-serve",
+	Short: "Run oi as a MCP server over stdio or streamable HTTP",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+		return mcpServe(ctx, mcpServeAddr)
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().StringVar(&mcpServeAddr, "http", "", "Serve streamable HTTP on the given address (e.g. :8080) instead of stdio")
+	rootCmd.AddCommand(mcpServeCmd)
+}
+
+func newMCPServer() *server.MCPServer {
+	s := server.NewMCPServer(
+		"oi",
+		Version,
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+	)
+	s.AddTool(mcp.NewTool(
+		"ask",
+		mcp.WithDescription("Ask a local Ollama model a question, optionally continuing a saved conversation"),
+		mcp.WithString("prompt", mcp.Required(), mcp.Description("The prompt to send")),
+		mcp.WithString("model", mcp.Description("Model to use, defaults to the configured default model")),
+		mcp.WithString("role", mcp.Description("Role to use, as defined in the oi settings")),
+		mcp.WithString("conversation_id", mcp.Description("ID or title of a saved conversation to continue")),
+	), mcpServeAsk)
+	s.AddTool(mcp.NewTool(
+		"list_conversations",
+		mcp.WithDescription("List saved conversations, most recent first"),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of conversations to return")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), mcpServeListConversations)
+	s.AddTool(mcp.NewTool(
+		"show_conversation",
+		mcp.WithDescription("Show the messages of a saved conversation"),
+		mcp.WithString("conversation_id", mcp.Required(), mcp.Description("ID or title of the conversation")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), mcpServeShowConversation)
+	s.AddTool(mcp.NewTool(
+		"list_models",
+		mcp.WithDescription("List the models available in the local Ollama instance"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), mcpServeListModels)
+	return s
+}
+
+func mcpServe(ctx context.Context, addr string) error {
+	s := newMCPServer()
+	if addr == "" {
+		if err := server.NewStdioServer(s).Listen(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			return modsError{err, "MCP server failed."}
+		}
+		return nil
+	}
+
+	srv := server.NewStreamableHTTPServer(s)
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) //nolint:mnd
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	if !config.Quiet {
+		fmt.Fprintf(os.Stderr, "Serving MCP on %s\n", stderrStyles().Link.Render("http://"+addr+"/mcp"))
+	}
+	if err := srv.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return modsError{err, "MCP server failed."}
+	}
+	return nil
+}
+
+// serveConversation is a saved conversation as returned by the MCP server.
+type serveConversation struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+	API       string    `json:"api,omitempty"`
+	Model     string    `json:"model,omitempty"`
+}
+
+func mcpServeListConversations(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	conversations, err := db.List()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not list conversations", err), nil
+	}
+	if limit := request.GetInt("limit", 0); limit > 0 && limit < len(conversations) {
+		conversations = conversations[:limit]
+	}
+	result := make([]serveConversation, 0, len(conversations))
+	for _, c := range conversations {
+		sc := serveConversation{
+			ID:        c.ID,
+			Title:     c.Title,
+			UpdatedAt: c.UpdatedAt,
+		}
+		if c.API != nil {
+			sc.API = *c.API
+		}
+		if c.Model != nil {
+			sc.Model = *c.Model
+		}
+		result = append(result, sc)
+	}
+	return mcp.NewToolResultJSON(map[string]any{"conversations": result}) //nolint:wrapcheck
+}
+
+func mcpServeShowConversation(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("conversation_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	convo, err := db.Find(id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not find the conversation", err), nil
+	}
+	messages, err := db.Messages(convo.ID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not read the conversation", err), nil
+	}
+	return mcp.NewToolResultText(proto.Conversation(messages).String()), nil
+}
+
+func mcpServeListModels(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, err := serveOllamaClient()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not setup ollama client", err), nil
+	}
+	resp, err := client.List(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not list models", err), nil
+	}
+	models := make([]string, 0, len(resp.Models))
+	for _, m := range resp.Models {
+		models = append(models, m.Name)
+	}
+	return mcp.NewToolResultStructured(
+		map[string]any{"models": models},
+		strings.Join(models, "\n"),
+	), nil
+}
+
+func serveOllamaClient() (*ollama.Client, error) {
+	var api API
+	for _, a := range config.APIs {
+		if a.Name == "ollama" {
+			api = a
+		}
+	}
+	occfg, err := ollamaConfig(&config, api)
+	if err != nil {
+		return nil, err
+	}
+	return ollama.New(occfg) //nolint:wrapcheck
+}
+
+func mcpServeAsk(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	prompt, err := request.RequireString("prompt")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cfg := config
+	cfg.Quiet = true
+	cfg.Prefix = ""
+	cfg.Role = request.GetString("role", cfg.Role)
+	if id := request.GetString("conversation_id", ""); id != "" {
+		convo, err := db.Find(id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("could not find the conversation", err), nil
+		}
+		cfg.cacheReadFromID = convo.ID
+		cfg.cacheWriteToID = convo.ID
+		cfg.cacheWriteToTitle = convo.Title
+		if convo.Model != nil {
+			cfg.Model = *convo.Model
+		}
+	} else {
+		cfg.cacheWriteToID = newConversationID()
+	}
+	cfg.Model = request.GetString("model", cfg.Model)
+
+	mods := newMods(ctx, stderrRenderer(), &cfg, db)
+	defer mods.cancel()
+	answer, err := mods.complete(ctx, prompt)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("could not complete the prompt", err), nil
+	}
+	if err := saveConversation(mods); err != nil {
+		return mcp.NewToolResultErrorFromErr("could not save the conversation", err), nil
+	}
+
+	return mcp.NewToolResultStructured(
+		map[string]any{
+			"conversation_id": cfg.cacheWriteToID,
+			"answer":          answer,
+		},
+		answer,
+	), nil
+}
+
+// complete runs a prompt to completion outside of the TUI, leaving the whole
+// conversation in m.messages and returning the answer.
+func (m *Mods) complete(ctx context.Context, prompt string) (string, error) {
+	cfg := m.Config
+	api, mod, err := m.resolveModel(cfg)
+	if err != nil {
+		return "", err
+	}
+	if mod.MaxChars == 0 {
+		mod.MaxChars = cfg.MaxInputChars
+	}
+	occfg, err := ollamaConfig(cfg, api)
+	if err != nil {
+		return "", err
+	}
+	if err := m.setupStreamContext(prompt, mod); err != nil {
+		return "", err
+	}
+
+	request := proto.Request{
+		Messages:    m.messages,
+		API:         mod.API,
+		Model:       mod.Name,
+		User:        cfg.User,
+		Temperature: ptrOrNil(cfg.Temperature),
+		TopP:        ptrOrNil(cfg.TopP),
+		TopK:        ptrOrNil(cfg.TopK),
+		Stop:        cfg.Stop,
+	}
+	if cfg.MaxTokens > 0 {
+		request.MaxTokens = &cfg.MaxTokens
+	}
+
+	client, err := ollama.New(occfg)
+	if err != nil {
+		return "", modsError{err, "Could not setup ollama client"}
+	}
+
+	st := client.Request(ctx, request)
+	var sb strings.Builder
+	for {
+		for st.Next() {
+			chunk, err := st.Current()
+			if err != nil && !errors.Is(err, stream.ErrNoContent) {
+				return "", err //nolint:wrapcheck
+			}
+			sb.WriteString(chunk.Content)
+		}
+		if err := st.Err(); err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		if len(st.CallTools()) == 0 {
+			break
+		}
+	}
+	m.messages = st.Messages()
+	return sb.String(), nil
+}
@@ -19,7 +19,6 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/GuntuAshok/oi/internal/cache"
 	"github.com/GuntuAshok/oi/internal/ollama"
 	"github.com/GuntuAshok/oi/internal/proto"
 	"github.com/GuntuAshok/oi/internal/stream"
@@ -62,7 +61,6 @@ type Mods struct {
 	height        int
 
 	db     *convoDB
-	cache  *cache.Conversations
 	Config *Config
 
 	content      []string
@@ -70,6 +68,12 @@ type Mods struct {
 
 	ctx      context.Context
 	streamed bool // NEW: Add this line (tracks if output was streamed live)
+
+	toolEvents   chan tea.Msg
+	confirming   *toolConfirmation
+	confirmMutex *sync.Mutex
+	toolStatus   string
+	toolRuns     *toolRuns
 }
 
 func newMods(
@@ -77,7 +81,6 @@ func newMods(
 	r *lipgloss.Renderer,
 	cfg *Config,
 	db *convoDB,
-	cache *cache.Conversations,
 ) *Mods {
 	gr, _ := glamour.NewTermRenderer(
 		glamour.WithEnvironmentConfig(),
@@ -85,21 +88,39 @@ func newMods(
 	)
 	vp := viewport.New(0, 0)
 	vp.GotoBottom()
+	ctx, cancel := context.WithCancel(ctx)
 	return &Mods{
-		Styles:       makeStyles(r),
-		glam:         gr,
-		state:        startState,
-		renderer:     r,
-		glamViewport: vp,
-		contentMutex: &sync.Mutex{},
-		db:           db,
-		cache:        cache,
-		Config:       cfg,
-		ctx:          ctx,
-		streamed:     false,
+		Styles:        makeStyles(r),
+		glam:          gr,
+		state:         startState,
+		renderer:      r,
+		glamViewport:  vp,
+		contentMutex:  &sync.Mutex{},
+		db:            db,
+		Config:        cfg,
+		ctx:           ctx,
+		cancelRequest: []context.CancelFunc{cancel},
+		streamed:      false,
+		toolEvents:    make(chan tea.Msg, toolEventsBuffer),
+		confirmMutex:  &sync.Mutex{},
+		toolRuns:      &toolRuns{},
 	}
 }
 
+const toolEventsBuffer = 16
+
+// toolConfirmation is a tea.Msg asking the user to allow a tool call.
+type toolConfirmation struct {
+	tool    string
+	summary string
+	reply   chan bool
+}
+
+// toolProgress is a tea.Msg reporting the status of a running tool call.
+type toolProgress struct {
+	status string
+}
+
 // completionInput is a tea.Msg that wraps the content read from stdin.
 type completionInput struct {
 	content string
@@ -114,7 +135,7 @@ type completionOutput struct {
 
 // Init implements tea.Model.
 func (m *Mods) Init() tea.Cmd {
-	return m.findCacheOpsDetails()
+	return tea.Batch(m.findCacheOpsDetails(), m.waitForToolEvent())
 }
 
 // Update implements tea.Model.
@@ -166,6 +187,7 @@ func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = requestState
 		cmds = append(cmds, m.startCompletionCmd(msg.content))
 	case completionOutput:
+		m.toolStatus = ""
 		if msg.stream == nil {
 			m.state = doneState
 			return m, m.quit
@@ -188,7 +210,20 @@ func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.glamViewport.Width = m.width
 		m.glamViewport.Height = m.height
 		return m, nil
+	case toolConfirmation:
+		m.confirming = &msg
+		return m, nil
+	case toolProgress:
+		m.toolStatus = msg.status
+		return m, m.waitForToolEvent()
 	case tea.KeyMsg:
+		if m.confirming != nil {
+			m.confirming.reply <- msg.String() == "y" || msg.String() == "Y"
+			m.confirming = nil
+			if msg.String() != "ctrl+c" {
+				return m, m.waitForToolEvent()
+			}
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.state = doneState
@@ -214,19 +249,27 @@ func (m Mods) viewportNeeded() bool {
 
 // View implements tea.Model.
 func (m *Mods) View() string {
+	if m.confirming != nil {
+		return fmt.Sprintf(
+			"Allow %s to %s? %s\n",
+			m.Styles.InlineCode.Render(m.confirming.tool),
+			m.confirming.summary,
+			m.Styles.Comment.Render("(y/N)"),
+		)
+	}
 	switch m.state {
 	case errorState:
 		return ""
 	case requestState:
 		if !m.Config.Quiet {
-			return m.anim.View()
+			return m.withToolStatus(m.anim.View())
 		}
 	case responseState:
 		if !m.Config.Raw && isOutputTTY() {
 			if m.viewportNeeded() {
-				return m.glamViewport.View()
+				return m.withToolStatus(m.glamViewport.View())
 			}
-			return m.glamOutput
+			return m.withToolStatus(m.glamOutput)
 		}
 
 		if isOutputTTY() && !m.Config.Raw {
@@ -248,11 +291,103 @@ func (m *Mods) View() string {
 	return ""
 }
 
+// withToolStatus adds the status of the running tool call, if any, below the
+// given view.
+func (m *Mods) withToolStatus(view string) string {
+	if m.toolStatus == "" || m.Config.Quiet {
+		return view
+	}
+	if !strings.HasSuffix(view, "\n") {
+		view += "\n"
+	}
+	return view + m.Styles.Comment.Render(m.toolStatus) + "\n"
+}
+
 func (m *Mods) quit() tea.Msg {
+	m.cancel()
+	return tea.Quit()
+}
+
+// cancel cancels any in-flight requests and tool calls.
+func (m *Mods) cancel() {
 	for _, cancel := range m.cancelRequest {
 		cancel()
 	}
-	return tea.Quit()
+}
+
+// waitForToolEvent waits for a running tool to report its progress or to ask
+// for the user's confirmation.
+func (m *Mods) waitForToolEvent() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-m.toolEvents:
+			return msg
+		case <-m.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// confirmTool asks the user to allow a tool call, blocking until they answer.
+// Calls are denied if oi is not running interactively.
+func (m *Mods) confirmTool(tool, summary string) bool {
+	if m.Config.Raw || !isOutputTTY() {
+		return false
+	}
+	// tools may run concurrently, ask about one call at a time.
+	m.confirmMutex.Lock()
+	defer m.confirmMutex.Unlock()
+	reply := make(chan bool, 1)
+	select {
+	case m.toolEvents <- toolConfirmation{tool, summary, reply}:
+	case <-m.ctx.Done():
+		return false
+	}
+	select {
+	case ok := <-reply:
+		return ok
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+// reportToolProgress shows the given status while a tool runs. Updates are
+// dropped if the TUI can't keep up.
+func (m *Mods) reportToolProgress(status string) {
+	select {
+	case m.toolEvents <- toolProgress{status}:
+	default:
+	}
+}
+
+// toolRuns tracks the tool calls currently running, to report each of them
+// as it finishes.
+type toolRuns struct {
+	mu      sync.Mutex
+	running []string
+}
+
+func (r *toolRuns) start(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = append(r.running, name)
+	return fmt.Sprintf("Running %s…", strings.Join(r.running, ", "))
+}
+
+func (r *toolRuns) finish(name string, err error) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := slices.Index(r.running, name); i >= 0 {
+		r.running = slices.Delete(r.running, i, i+1)
+	}
+	status := "Finished " + name
+	if err != nil {
+		status = "Failed " + name
+	}
+	if len(r.running) > 0 {
+		status += fmt.Sprintf(", running %s…", strings.Join(r.running, ", "))
+	}
+	return status
 }
 
 func (m *Mods) retry(content string, err modsError) tea.Msg {
@@ -273,7 +408,6 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 	return func() tea.Msg {
 		var mod Model
 		var api API
-		var occfg ollama.Config
 
 		cfg := m.Config
 		api, mod, err := m.resolveModel(cfg)
@@ -294,28 +428,32 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 			}
 		}
 
-		occfg = ollama.DefaultConfig()
-		if api.BaseURL != "" {
-			occfg.BaseURL = api.BaseURL
-		}
-
-		if cfg.HTTPProxy != "" {
-			proxyURL, err := url.Parse(cfg.HTTPProxy)
-			if err != nil {
-				return modsError{err, "There was an error parsing your proxy URL."}
-			}
-			httpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
-			occfg.HTTPClient = httpClient
+		occfg, err := ollamaConfig(cfg, api)
+		if err != nil {
+			return err
 		}
 
 		if mod.MaxChars == 0 {
 			mod.MaxChars = cfg.MaxInputChars
 		}
 
-		ctx, cancel := context.WithTimeout(m.ctx, m.Config.MCPTimeout)
-		m.cancelRequest = append(m.cancelRequest, cancel)
+		sel, err := toolSelectionFor(cfg)
+		if err != nil {
+			return modsError{err, "Invalid tool selection."}
+		}
 
-		tools, err := mcpTools(ctx)
+		tools, err := mcpTools(m.ctx, sel)
+		if err != nil {
+			return err
+		}
+
+		builtin, err := newBuiltinTools(cfg, sel, m.confirmTool)
+		if err != nil {
+			return err
+		}
+		tools = withBuiltinTools(tools, builtin)
+
+		limits, err := toolLimits(cfg)
 		if err != nil {
 			return err
 		}
@@ -324,6 +462,12 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 			return err
 		}
 
+		client, err := ollama.New(occfg)
+		if err != nil {
+			return modsError{err, "Could not setup ollama client"}
+		}
+		vision := len(tools) > 0 && client.SupportsVision(m.ctx, mod.Name)
+
 		request := proto.Request{
 			Messages:    m.messages,
 			API:         mod.API,
@@ -334,21 +478,31 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 			TopK:        ptrOrNil(cfg.TopK),
 			Stop:        cfg.Stop,
 			Tools:       tools,
-			ToolCaller: func(name string, data []byte) (string, error) {
-				ctx, cancel := context.WithTimeout(m.ctx, m.Config.MCPTimeout)
-				m.cancelRequest = append(m.cancelRequest, cancel)
-				return toolCall(ctx, name, data)
+			ToolCaller: func(name string, data []byte) (proto.ToolResult, error) {
+				m.reportToolProgress(m.toolRuns.start(name))
+				result, ok, err := builtinToolCall(m.ctx, builtin, name, data)
+				if !ok {
+					if server, tool, _ := splitToolName(name); !sel.includes(server, tool) {
+						err = fmt.Errorf("tool is not available: %q", name)
+					} else {
+						result, err = toolCall(m.ctx, name, data, mcpHooks{
+							progress: m.reportToolProgress,
+							confirm:  m.confirmTool,
+							model:    mod.Name,
+							vision:   vision,
+						})
+					}
+				}
+				m.reportToolProgress(m.toolRuns.finish(name, err))
+				return result, err
 			},
+			ToolLimits: limits,
+			Vision:     vision,
 		}
 		if cfg.MaxTokens > 0 {
 			request.MaxTokens = &cfg.MaxTokens
 		}
 
-		client, err := ollama.New(occfg)
-		if err != nil {
-			return modsError{err, "Could not setup ollama client"}
-		}
-
 		stream := client.Request(m.ctx, request)
 		return m.receiveCompletionStreamCmd(completionOutput{
 			stream: stream,
@@ -359,6 +513,24 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 	}
 }
 
+// ollamaConfig returns the client configuration for the given API.
+func ollamaConfig(cfg *Config, api API) (ollama.Config, error) {
+	occfg := ollama.DefaultConfig()
+	if api.BaseURL != "" {
+		occfg.BaseURL = api.BaseURL
+	}
+
+	if cfg.HTTPProxy != "" {
+		proxyURL, err := url.Parse(cfg.HTTPProxy)
+		if err != nil {
+			return occfg, modsError{err, "There was an error parsing your proxy URL."}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		occfg.HTTPClient = httpClient
+	}
+	return occfg, nil
+}
+
 func (m *Mods) receiveCompletionStreamCmd(msg completionOutput) tea.Cmd {
 	return func() tea.Msg {
 		if msg.stream.Next() {
@@ -384,6 +556,7 @@ func (m *Mods) receiveCompletionStreamCmd(msg completionOutput) tea.Cmd {
 			errh:   msg.errh,
 		}
 		for _, call := range results {
+			call.Verbosity = m.Config.toolVerbosity
 			toolMsg.content += call.String()
 		}
 		if len(results) == 0 {
@@ -419,7 +592,7 @@ func (m *Mods) findCacheOpsDetails() tea.Cmd {
 			}
 			if found != nil {
 				readID = found.ID
-				if found.Model != nil && found.API != nil {
+				if found.Model != nil && found.API != nil && !m.Config.cacheKeepModel {
 					model = *found.Model
 					api = *found.API
 				}
@@ -493,14 +666,14 @@ func (m *Mods) readStdinCmd() tea.Msg {
 
 func (m *Mods) readFromCache() tea.Cmd {
 	return func() tea.Msg {
-		var messages []proto.Message
 		id := m.Config.cacheReadFromID
-		if err := m.cache.Read(id, &messages); err != nil {
+		messages, err := m.db.Messages(id)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "[DEBUG] Cache read failed for ID %s: %v\n", id[:8], err) // Temp
 			return modsError{err, "There was an error loading the conversation."}
 		}
 		fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d messages from cache %s\n", len(messages), id[:8]) // Temp
-		output := proto.Conversation(messages).String()
+		output := proto.Conversation(messages).Render(m.Config.toolVerbosity)
 		fmt.Fprintf(os.Stderr, "[DEBUG] Generated output len: %d chars\n", len(output)) // Temp
 		m.appendToOutput(output)
 		return completionOutput{
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const megabyte = 1024 * 1024
+
+// applyRetention deletes the conversations beyond the limits of the
+// retention policy, from the oldest, except for the one just saved and the
+// pinned or titled ones if they are kept. It returns how many were deleted.
+func applyRetention(policy RetentionConfig, keepID string) (int, error) {
+	if !policy.enabled() {
+		return 0, nil
+	}
+	conversations, err := db.Stored()
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	kept := func(c storedConversation) bool {
+		return c.ID == keepID ||
+			(c.Pinned && policy.KeepPinned) ||
+			(c.TitleSource != nil && *c.TitleSource == titleFromUser && policy.KeepTitled)
+	}
+
+	// The conversations always kept count towards the limits first.
+	var count int
+	var size int64
+	for _, c := range conversations {
+		if kept(c) {
+			count++
+			size += c.Size
+		}
+	}
+
+	var deleted int
+	for _, c := range conversations {
+		if kept(c) {
+			continue
+		}
+		expired := (policy.MaxAge > 0 && time.Since(c.UpdatedAt) > policy.MaxAge) ||
+			(policy.MaxCount > 0 && count+1 > policy.MaxCount) ||
+			(policy.MaxSize > 0 && size+c.Size > policy.MaxSize*megabyte)
+		if !expired {
+			count++
+			size += c.Size
+			continue
+		}
+		if err := db.Delete(c.ID); err != nil {
+			return deleted, err //nolint:wrapcheck
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// enforceRetention applies the retention policy after saving a conversation.
+func enforceRetention(cfg *Config, savedID string) error {
+	deleted, err := applyRetention(cfg.Retention, savedID)
+	if err != nil {
+		return modsError{err, "Could not delete old conversations."}
+	}
+	if deleted > 0 && !cfg.Quiet {
+		fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(
+			fmt.Sprintf("Deleted %d old conversations per the retention policy.", deleted),
+		))
+	}
+	return nil
+}
+
+// skipPipelineSave returns whether the conversation isn't saved because
+// this is a pipeline run, which skip-pipelines doesn't save unless it has a
+// title.
+func skipPipelineSave(cfg *Config) bool {
+	return cfg.Retention.SkipPipelines &&
+		cfg.Title == "" &&
+		(!isInputTTY() || !isOutputTTY())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the full-text search index of the saved conversations",
+	Args:  cobra.NoArgs,
+	RunE: func(*cobra.Command, []string) error {
+		if err := db.RebuildSearchIndex(); err != nil {
+			return modsError{err, "Could not rebuild the search index."}
+		}
+		if !config.Quiet {
+			fmt.Fprintln(os.Stderr, "Search index rebuilt.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
+
+// listConversations returns the conversations matching --search, or all of
+// them, filtered with the --list filters.
+func listConversations() ([]Conversation, error) {
+	var conversations []Conversation
+	var err error
+	if config.Search != "" {
+		conversations, err = db.Search(config.Search, 0)
+	} else {
+		conversations, err = db.List()
+	}
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return filterConversations(conversations), nil
+}
+
+// highlightSnippet renders a search snippet on a single line, with the
+// matches in the given style.
+func highlightSnippet(snippet string, match lipgloss.Style) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	var sb strings.Builder
+	for {
+		before, rest, ok := strings.Cut(snippet, searchMatchStart)
+		sb.WriteString(before)
+		if !ok {
+			break
+		}
+		word, after, _ := strings.Cut(rest, searchMatchEnd)
+		sb.WriteString(match.Render(word))
+		snippet = after
+	}
+	return sb.String()
+}
@@ -13,7 +13,8 @@ func (m *Mods) setupStreamContext(content string, mod Model) error {
 
 	// 2. Attempt to load history from cache FIRST.
 	if !cfg.NoCache && cfg.cacheReadFromID != "" {
-		if err := m.cache.Read(cfg.cacheReadFromID, &m.messages); err != nil {
+		messages, err := m.db.Messages(cfg.cacheReadFromID)
+		if err != nil {
 			return modsError{
 				err: err,
 				reason: fmt.Sprintf(
@@ -23,6 +24,10 @@ func (m *Mods) setupStreamContext(content string, mod Model) error {
 				),
 			}
 		}
+		if cfg.cacheDropLastTurn {
+			messages = messagesUpToTurn(messages, countTurns(messages)-1)
+		}
+		m.messages = messages
 	}
 
 	// 3. Only add system/role prompts if this is a NEW conversation
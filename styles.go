@@ -21,6 +21,7 @@ type styles struct {
 	Quote,
 	ConversationList,
 	SHA1,
+	SearchMatch,
 	Timeago lipgloss.Style
 }
 
@@ -42,6 +43,7 @@ func makeStyles(r *lipgloss.Renderer) (s styles) {
 	s.Pipe = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#8470FF", Dark: "#745CFF"})
 	s.ConversationList = r.NewStyle().Padding(0, 1)
 	s.SHA1 = s.Flag
+	s.SearchMatch = s.Quote.Bold(true)
 	s.Timeago = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999", Dark: "#555"})
 	return s
 }
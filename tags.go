@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Orders of the listed conversations.
+const (
+	sortUpdated = "updated"
+	sortOldest  = "oldest"
+	sortTitle   = "title"
+)
+
+var listSorts = []string{sortUpdated, sortOldest, sortTitle}
+
+// conversationCmd groups the commands managing saved conversations, so they
+// take a single word away from the prompts.
+var conversationCmd = &cobra.Command{
+	Use:   "conversation",
+	Short: "Tag, pin and annotate saved conversations",
+	Args:  cobra.NoArgs,
+}
+
+var tagRemove bool
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <id|title> <tag>...",
+	Short: "Tag a saved conversation",
+	Example: `  oi conversation tag 3f2a work nginx
+  oi conversation tag 3f2a --remove nginx`,
+	Args: cobra.MinimumNArgs(2), //nolint:mnd
+	RunE: func(_ *cobra.Command, args []string) error {
+		convo, err := db.Find(args[0])
+		if err != nil {
+			return modsError{err, "Could not find the conversation."}
+		}
+		tags, err := normalizeTags(args[1:])
+		if err != nil {
+			return err
+		}
+		if tagRemove {
+			err = db.RemoveTags(convo.ID, tags)
+		} else {
+			err = db.AddTags(convo.ID, tags)
+		}
+		if err != nil {
+			return modsError{err, "Could not tag the conversation."}
+		}
+		return nil
+	},
+	ValidArgsFunction: completeConversations,
+}
+
+var pinCmd = &cobra.Command{
+	Use:               "pin <id|title>...",
+	Short:             "Pin saved conversations",
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              func(_ *cobra.Command, args []string) error { return setPinned(args, true) },
+	ValidArgsFunction: completeConversations,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <id|title>...",
+	Short:             "Unpin saved conversations",
+	Args:              cobra.MinimumNArgs(1),
+	RunE:              func(_ *cobra.Command, args []string) error { return setPinned(args, false) },
+	ValidArgsFunction: completeConversations,
+}
+
+var noteClear bool
+
+var noteCmd = &cobra.Command{
+	Use:   "note <id|title> [note]",
+	Short: "Show or set the note of a saved conversation",
+	Example: `  oi conversation note 3f2a "Fixed in v1.2, see the nginx config"
+  oi conversation note 3f2a
+  oi conversation note 3f2a --clear`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		convo, err := db.Find(args[0])
+		if err != nil {
+			return modsError{err, "Could not find the conversation."}
+		}
+		if len(args) == 1 && !noteClear {
+			convo, err = db.Meta(convo.ID)
+			if err != nil {
+				return modsError{err, "Could not read the note."}
+			}
+			if convo.Note != nil {
+				fmt.Println(*convo.Note)
+			}
+			return nil
+		}
+		if err := db.SetNote(convo.ID, strings.TrimSpace(strings.Join(args[1:], " "))); err != nil {
+			return modsError{err, "Could not save the note."}
+		}
+		return nil
+	},
+	ValidArgsFunction: completeConversations,
+}
+
+func init() {
+	tagCmd.Flags().BoolVarP(&tagRemove, "remove", "r", false, "Remove the tags instead")
+	noteCmd.Flags().BoolVar(&noteClear, "clear", false, "Remove the note")
+	conversationCmd.AddCommand(tagCmd, pinCmd, unpinCmd, noteCmd)
+	rootCmd.AddCommand(conversationCmd)
+}
+
+func completeConversations(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	results, _ := db.Completions(toComplete)
+	return results, cobra.ShellCompDirectiveDefault
+}
+
+func setPinned(args []string, pinned bool) error {
+	for _, arg := range args {
+		convo, err := db.Find(arg)
+		if err != nil {
+			return modsError{err, "Could not find the conversation."}
+		}
+		if err := db.SetPinned(convo.ID, pinned); err != nil {
+			return modsError{err, "Could not pin the conversation."}
+		}
+	}
+	return nil
+}
+
+// normalizeTags trims the tags and a leading #, tags can't be empty nor have
+// spaces or commas.
+func normalizeTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+			return nil, newUserErrorf("Invalid tag %q, tags can't be empty nor have spaces or commas.", tag)
+		}
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+// filterConversations keeps the conversations matching the --list filters,
+// sorted and limited.
+func filterConversations(conversations []Conversation) []Conversation {
+	result := make([]Conversation, 0, len(conversations))
+	for _, c := range conversations {
+		if matchesListFilters(c) {
+			result = append(result, c)
+		}
+	}
+	if config.Search != "" && len(result) > searchLimit {
+		// searches keep their best matches, once filtered.
+		result = result[:searchLimit]
+	}
+
+	switch config.Sort {
+	case sortOldest:
+		slices.SortStableFunc(result, func(a, b Conversation) int {
+			return a.UpdatedAt.Compare(b.UpdatedAt)
+		})
+	case sortTitle:
+		slices.SortStableFunc(result, func(a, b Conversation) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		})
+	case sortUpdated:
+		if config.Search == "" {
+			break
+		}
+		// Search results are sorted by relevance otherwise.
+		slices.SortStableFunc(result, func(a, b Conversation) int {
+			return b.UpdatedAt.Compare(a.UpdatedAt)
+		})
+	}
+
+	if config.Limit > 0 && len(result) > config.Limit {
+		result = result[:config.Limit]
+	}
+	return result
+}
+
+func matchesListFilters(c Conversation) bool {
+	if config.Pinned && !c.Pinned {
+		return false
+	}
+	if !config.Since.IsZero() && c.UpdatedAt.Before(config.Since) {
+		return false
+	}
+	if !config.Until.IsZero() && !c.UpdatedAt.Before(config.Until) {
+		return false
+	}
+	if config.listModel != "" && (c.Model == nil || *c.Model != config.listModel) {
+		return false
+	}
+	if config.listAPI != "" && (c.API == nil || *c.API != config.listAPI) {
+		return false
+	}
+	tags := c.TagList()
+	for _, tag := range config.Tags {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// renderListMeta renders whether the conversation is pinned and its tags.
+func renderListMeta(c Conversation) string {
+	var parts []string
+	if c.Pinned {
+		parts = append(parts, "pinned")
+	}
+	for _, tag := range c.TagList() {
+		parts = append(parts, "#"+tag)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return stdoutStyles().Comment.Render(strings.Join(parts, " "))
+}
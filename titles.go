@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/GuntuAshok/oi/internal/proto"
+	"github.com/spf13/cobra"
+)
+
+// Where the title of a conversation comes from.
+const (
+	titleFromPrompt = "prompt"
+	titleFromUser   = "user"
+	titleFromModel  = "model"
+)
+
+const (
+	titleTimeout    = 30 * time.Second
+	titleMaxRunes   = 80
+	titleInputRunes = 2000
+)
+
+const titlePrompt = `Write a title of at most six words for the conversation below.
+Answer with the title only, without quotes, markdown or a final period.`
+
+var (
+	retitleAll   bool
+	retitleForce bool
+)
+
+var retitleCmd = &cobra.Command{
+	Use:   "retitle [id|title...]",
+	Short: "Generate the titles of saved conversations with the title model",
+	Example: `  oi retitle 3f2a
+  oi retitle --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.TitleModel == "" {
+			return newUserErrorf("Set %s in your configuration file to generate titles.", stderrStyles().InlineCode.Render("title-model"))
+		}
+		var conversations []Conversation
+		switch {
+		case len(args) > 0:
+			for _, arg := range args {
+				convo, err := db.Find(arg)
+				if err != nil {
+					return modsError{err, "Could not find the conversation."}
+				}
+				conversations = append(conversations, *convo)
+			}
+		case retitleAll:
+			all, err := db.List()
+			if err != nil {
+				return modsError{err, "Could not list the conversations."}
+			}
+			conversations = all
+		default:
+			return newUserErrorf("Missing conversation to retitle, give an ID or title, or %s.", stderrStyles().Flag.Render("--all"))
+		}
+
+		var retitled int
+		for _, convo := range conversations {
+			if !retitleForce && userTitled(convo) {
+				continue
+			}
+			messages, err := db.Messages(convo.ID)
+			if errors.Is(err, errNoMessages) && len(args) == 0 {
+				continue
+			}
+			if err != nil {
+				return modsError{err, "Could not read the conversation."}
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), titleTimeout)
+			title, err := generateTitle(ctx, messages)
+			cancel()
+			if err != nil {
+				return modsError{err, "Could not generate the title."}
+			}
+			if title == "" {
+				continue
+			}
+			// the title may have been set while it was generated.
+			if latest, err := db.Find(convo.ID); err == nil && !retitleForce && userTitled(*latest) {
+				continue
+			}
+			if err := saveTitle(convo, title); err != nil {
+				return modsError{err, "Could not save the title."}
+			}
+			retitled++
+			if !config.Quiet {
+				fmt.Fprintln(
+					os.Stderr,
+					stderrStyles().InlineCode.Render(convo.ID[:sha1short]),
+					stderrStyles().Comment.Render(title),
+				)
+			}
+		}
+		if !config.Quiet {
+			fmt.Fprintf(os.Stderr, "Retitled %d conversations.\n", retitled)
+		}
+		return nil
+	},
+	ValidArgsFunction: func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		results, _ := db.Completions(toComplete)
+		return results, cobra.ShellCompDirectiveDefault
+	},
+}
+
+func init() {
+	flags := retitleCmd.Flags()
+	flags.BoolVar(&retitleAll, "all", false, "Retitle all the saved conversations, except the ones titled with --title")
+	flags.BoolVar(&retitleForce, "force", false, "Also retitle the conversations titled with --title")
+	rootCmd.AddCommand(retitleCmd)
+}
+
+// titleInBackground generates the title of the conversation with the title
+// model, if any, in a detached oi retitle process so oi exits right away.
+// Failures are ignored, the conversation keeps the title from its prompt.
+func titleInBackground(id string) {
+	if config.TitleModel == "" {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, "retitle", id) //nolint:gosec
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	_ = cmd.Process.Release()
+}
+
+// userTitled returns whether the conversation was titled with --title.
+func userTitled(convo Conversation) bool {
+	return convo.TitleSource != nil && *convo.TitleSource == titleFromUser
+}
+
+// generateTitle asks the title model for a title for the first exchange of
+// the conversation.
+func generateTitle(ctx context.Context, messages []proto.Message) (string, error) {
+	var input strings.Builder
+	for _, msg := range messagesUpToTurn(messages, 1) {
+		if msg.Content == "" {
+			continue
+		}
+		switch msg.Role {
+		case proto.RoleUser:
+			fmt.Fprintf(&input, "User: %s\n\n", truncateRunes(msg.Content, titleInputRunes))
+		case proto.RoleAssistant:
+			fmt.Fprintf(&input, "Assistant: %s\n\n", truncateRunes(msg.Content, titleInputRunes))
+		}
+	}
+	if input.Len() == 0 {
+		return "", nil
+	}
+
+	client, err := serveOllamaClient()
+	if err != nil {
+		return "", err
+	}
+	answer, err := client.Complete(ctx, proto.Request{
+		Model: config.TitleModel,
+		Messages: []proto.Message{
+			{Role: proto.RoleSystem, Content: titlePrompt},
+			{Role: proto.RoleUser, Content: input.String()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not generate title: %w", err)
+	}
+	return cleanTitle(answer.Content), nil
+}
+
+// cleanTitle keeps the first non-empty line of the answer, without the
+// quotes and markdown small models tend to add.
+func cleanTitle(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#*-> ")
+		line = strings.TrimPrefix(line, "Title:")
+		line = strings.Trim(strings.TrimSpace(line), "\"'`*_.")
+		if line != "" {
+			return truncateRunes(line, titleMaxRunes)
+		}
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// saveTitle saves the title generated for the conversation.
+func saveTitle(convo Conversation, title string) error {
+	var api, model string
+	if convo.API != nil {
+		api = *convo.API
+	}
+	if convo.Model != nil {
+		model = *convo.Model
+	}
+	if err := db.Save(convo.ID, title, api, model, nil); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return db.SetTitleSource(convo.ID, titleFromModel) //nolint:wrapcheck
+}
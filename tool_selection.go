@@ -0,0 +1,159 @@
+package main
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/GuntuAshok/oi/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolsNone disables all tools.
+const toolsNone = "none"
+
+// toolPattern selects the tools of the servers matching server whose name
+// matches tool. Both are glob patterns.
+type toolPattern struct {
+	server, tool string
+}
+
+func (p toolPattern) matchServer(server string) bool {
+	ok, _ := path.Match(p.server, server)
+	return ok
+}
+
+func (p toolPattern) match(server, tool string) bool {
+	ok, _ := path.Match(p.tool, tool)
+	return ok && p.matchServer(server)
+}
+
+// toolSelection is the set of tools exposed to the model. A nil selection
+// exposes the tools of all enabled MCP servers and no built-in tools.
+type toolSelection []toolPattern
+
+// toolSelectionFor returns the tools selected by the --tools flag or, if it
+// wasn't given, by the current role.
+//
+// Each entry is either none, a server name, a server_tool name, or the name
+// of a built-in tool (all meaning every built-in tool). Server and tool names
+// may be glob patterns.
+func toolSelectionFor(cfg *Config) (toolSelection, error) {
+	entries := cfg.Tools
+	if entries == nil {
+		entries = cfg.RoleTools[cfg.Role]
+	}
+	if entries == nil {
+		return nil, nil
+	}
+
+	sel := toolSelection{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == toolsNone:
+			return toolSelection{}, nil
+		case entry == tools.All:
+			sel = append(sel, toolPattern{tools.ServerName, "*"})
+		case tools.IsTool(entry):
+			sel = append(sel, toolPattern{tools.ServerName, entry})
+		default:
+			if _, err := path.Match(entry, ""); err != nil {
+				return nil, newUserErrorf("invalid pattern %q: %s", entry, err)
+			}
+			p, ok := parseToolPattern(entry)
+			if !ok {
+				return nil, newUserErrorf("unknown tool or MCP server %q", entry)
+			}
+			sel = append(sel, p)
+		}
+	}
+	return sel, nil
+}
+
+// parseToolPattern parses a server or server_tool entry. Server names may
+// have underscores too, so the entry is split at the last underscore that
+// leaves a server matching the built-in tools or a configured MCP server.
+func parseToolPattern(entry string) (toolPattern, bool) {
+	if isKnownToolServer(entry) {
+		return toolPattern{entry, "*"}, true
+	}
+	for i := len(entry) - 1; i > 0; i-- {
+		if entry[i] == '_' && isKnownToolServer(entry[:i]) {
+			return toolPattern{entry[:i], entry[i+1:]}, true
+		}
+	}
+	return toolPattern{}, false
+}
+
+// splitToolName splits the server_tool name of a MCP tool at the end of the
+// longest configured server name it starts with.
+func splitToolName(name string) (string, string, bool) {
+	var server, tool string
+	for sname := range config.MCPServers {
+		if rest, ok := strings.CutPrefix(name, sname+"_"); ok && len(sname) > len(server) {
+			server, tool = sname, rest
+		}
+	}
+	return server, tool, server != ""
+}
+
+// isKnownToolServer reports whether pattern matches the built-in tools or
+// any configured MCP server.
+func isKnownToolServer(pattern string) bool {
+	p := toolPattern{server: pattern}
+	if p.matchServer(tools.ServerName) {
+		return true
+	}
+	for name := range config.MCPServers {
+		if p.matchServer(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesServer reports whether any tool of the given server may be
+// selected, so servers that aren't can be skipped altogether.
+func (s toolSelection) includesServer(server string) bool {
+	if s == nil {
+		return server != tools.ServerName
+	}
+	return slices.ContainsFunc(s, func(p toolPattern) bool {
+		return p.matchServer(server)
+	})
+}
+
+// includes reports whether the given tool is selected.
+func (s toolSelection) includes(server, tool string) bool {
+	if s == nil {
+		return server != tools.ServerName
+	}
+	return slices.ContainsFunc(s, func(p toolPattern) bool {
+		return p.match(server, tool)
+	})
+}
+
+// filter removes the tools that are not selected.
+func (s toolSelection) filter(servers map[string][]mcp.Tool) map[string][]mcp.Tool {
+	result := map[string][]mcp.Tool{}
+	for server, serverTools := range servers {
+		for _, tool := range serverTools {
+			if s.includes(server, tool.Name) {
+				result[server] = append(result[server], tool)
+			}
+		}
+	}
+	return result
+}
+
+// builtinToolNames returns the selected built-in tools.
+func (s toolSelection) builtinToolNames() []string {
+	var names []string
+	for _, name := range tools.Names() {
+		if s.includes(tools.ServerName, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/GuntuAshok/oi/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolSelectionFor(t *testing.T) {
+	servers := config.MCPServers
+	t.Cleanup(func() { config.MCPServers = servers })
+	config.MCPServers = map[string]MCPServerConfig{
+		"git":        {Command: "uvx"},
+		"github":     {Command: "gh"},
+		"my_server":  {Command: "mine"},
+		"my":         {Command: "my"},
+		"filesystem": {Command: "npx"},
+	}
+
+	for name, tc := range map[string]struct {
+		tools     []string
+		role      string
+		roleTools map[string][]string
+		want      toolSelection
+		err       string
+	}{
+		"no tools": {
+			want: nil,
+		},
+		"none": {
+			tools: []string{"git", toolsNone},
+			want:  toolSelection{},
+		},
+		"whole server": {
+			tools: []string{"git"},
+			want:  toolSelection{{"git", "*"}},
+		},
+		"server tool": {
+			tools: []string{"git_status"},
+			want:  toolSelection{{"git", "status"}},
+		},
+		"server with underscores": {
+			tools: []string{"my_server", "my_server_read_file", "my_read"},
+			want: toolSelection{
+				{"my_server", "*"},
+				{"my_server", "read_file"},
+				{"my", "read"},
+			},
+		},
+		"glob patterns": {
+			tools: []string{"git*", "file*_read_*", "github_list_*"},
+			want: toolSelection{
+				{"git*", "*"},
+				{"file*", "read_*"},
+				{"github", "list_*"},
+			},
+		},
+		"builtin tools": {
+			tools: []string{tools.All, tools.ReadFile},
+			want: toolSelection{
+				{tools.ServerName, "*"},
+				{tools.ServerName, tools.ReadFile},
+			},
+		},
+		"unknown server": {
+			tools: []string{"gitlab_issues"},
+			err:   `unknown tool or MCP server "gitlab_issues"`,
+		},
+		"invalid pattern": {
+			tools: []string{"git_[status"},
+			err:   `invalid pattern "git_[status"`,
+		},
+		"role tools": {
+			role:      "reviewer",
+			roleTools: map[string][]string{"reviewer": {"git", tools.Grep}},
+			want: toolSelection{
+				{"git", "*"},
+				{tools.ServerName, tools.Grep},
+			},
+		},
+		"other role": {
+			role:      "writer",
+			roleTools: map[string][]string{"reviewer": {"git"}},
+			want:      nil,
+		},
+		"flag overrides role": {
+			tools:     []string{"github"},
+			role:      "reviewer",
+			roleTools: map[string][]string{"reviewer": {"git"}},
+			want:      toolSelection{{"github", "*"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sel, err := toolSelectionFor(&Config{
+				Tools:     tc.tools,
+				Role:      tc.role,
+				RoleTools: tc.roleTools,
+			})
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, sel)
+		})
+	}
+}
+
+func TestToolSelectionIncludes(t *testing.T) {
+	sel := toolSelection{{"git", "*"}, {"my_server", "read_*"}, {tools.ServerName, tools.Grep}}
+
+	require.True(t, sel.includesServer("git"))
+	require.True(t, sel.includesServer("my_server"))
+	require.False(t, sel.includesServer("github"))
+	require.True(t, sel.includes("git", "status"))
+	require.True(t, sel.includes("my_server", "read_file"))
+	require.False(t, sel.includes("my_server", "write_file"))
+	require.True(t, sel.includes(tools.ServerName, tools.Grep))
+	require.False(t, sel.includes(tools.ServerName, tools.RunCommand))
+	require.Equal(t, []string{tools.Grep}, sel.builtinToolNames())
+
+	require.Equal(t, map[string][]mcp.Tool{
+		"git":       {{Name: "status"}},
+		"my_server": {{Name: "read_file"}},
+	}, sel.filter(map[string][]mcp.Tool{
+		"git":       {{Name: "status"}},
+		"github":    {{Name: "issues"}},
+		"my_server": {{Name: "read_file"}, {Name: "write_file"}},
+	}))
+
+	t.Run("all servers", func(t *testing.T) {
+		var all toolSelection
+		require.True(t, all.includes("git", "status"))
+		require.False(t, all.includes(tools.ServerName, tools.Grep))
+		require.Empty(t, all.builtinToolNames())
+	})
+}
+
+func TestSplitToolName(t *testing.T) {
+	servers := config.MCPServers
+	t.Cleanup(func() { config.MCPServers = servers })
+	config.MCPServers = map[string]MCPServerConfig{
+		"my":        {Command: "my"},
+		"my_server": {Command: "mine"},
+	}
+
+	for name, tc := range map[string]struct {
+		in           string
+		server, tool string
+		ok           bool
+	}{
+		"simple":            {in: "my_tool", server: "my", tool: "tool", ok: true},
+		"longest server":    {in: "my_server_read_file", server: "my_server", tool: "read_file", ok: true},
+		"unknown server":    {in: "other_tool"},
+		"server only":       {in: "my"},
+		"tool with prefix":  {in: "my_servers", server: "my", tool: "servers", ok: true},
+		"no underscore":     {in: "tool"},
+		"underscore prefix": {in: "_tool"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, tool, ok := splitToolName(tc.in)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.server, server)
+			require.Equal(t, tc.tool, tool)
+		})
+	}
+}